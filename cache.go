@@ -0,0 +1,77 @@
+package sqlparser
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/msaf1980/sqlparser/query"
+)
+
+// CachingParser parses queries like ParseWithOptions, keeping the most recently used results in an LRU
+// cache keyed by the SQL text. It's safe for concurrent use.
+type CachingParser struct {
+	opts  Options
+	size  int
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	sql   string
+	query query.Query
+}
+
+// NewCachingParser returns a CachingParser with the default options, caching up to size queries. No query is
+// cached if size isn't positive.
+func NewCachingParser(size int) *CachingParser {
+	return NewCachingParserWithOptions(size, Options{})
+}
+
+// NewCachingParserWithOptions is like NewCachingParser, but the parser behavior is changed by opts.
+func NewCachingParserWithOptions(size int, opts Options) *CachingParser {
+	return &CachingParser{
+		opts:  opts,
+		size:  size,
+		order: list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// Parse is like ParseWithOptions. A cached query is returned as a Clone, so the caller may modify it. Only
+// queries parsed without error are cached.
+func (c *CachingParser) Parse(sql string) (query.Query, error) {
+	c.mu.Lock()
+	if e, ok := c.items[sql]; ok {
+		c.order.MoveToFront(e)
+		q := e.Value.(*cacheEntry).query.Clone()
+		c.mu.Unlock()
+		return q, nil
+	}
+	c.mu.Unlock()
+
+	q, err := ParseWithOptions(sql, c.opts)
+	if err != nil || c.size <= 0 {
+		return q, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[sql]; !ok {
+		// parsed concurrently otherwise, the cached query is the same
+		c.items[sql] = c.order.PushFront(&cacheEntry{sql: sql, query: q.Clone()})
+		if c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheEntry).sql)
+		}
+	}
+	return q, nil
+}
+
+// Len returns the number of cached queries
+func (c *CachingParser) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,135 @@
+package sqlparser
+
+import (
+	"time"
+
+	"github.com/msaf1980/sqlparser/query"
+)
+
+// Dialect selects the database specific SQL extensions accepted by the parser
+type Dialect int
+
+const (
+	// DialectGeneric is the zero value for a Dialect, only common SQL syntax is accepted
+	DialectGeneric Dialect = iota
+	// DialectPostgres enables PostgreSQL extensions, e.g. IS [NOT] DISTINCT FROM
+	DialectPostgres
+	// DialectSQLServer enables Microsoft SQL Server extensions, e.g. SELECT TOP 10
+	DialectSQLServer
+	// DialectMySQL enables MySQL extensions, e.g. double quoted strings
+	DialectMySQL
+)
+
+// DialectString is a string slice with the names of all dialects in order
+var DialectString = []string{
+	"Generic",
+	"Postgres",
+	"SQLServer",
+	"MySQL",
+}
+
+// DoubleQuotedStrings checks if double quotes delimit string values, like single quotes, e.g.
+// "2021-01-01". Otherwise double quotes delimit identifiers, as in standard SQL.
+func (d Dialect) DoubleQuotedStrings() bool {
+	return d == DialectMySQL
+}
+
+// stringQuote returns the quote delimiting strings besides the single quote, 0 if there is none
+func (o Options) stringQuote() byte {
+	if o.StringQuote == '"' || o.StringQuote == '`' {
+		return byte(o.StringQuote)
+	}
+	if o.Dialect.DoubleQuotedStrings() {
+		return '"'
+	}
+	return 0
+}
+
+// Options changes the parser behavior. The zero value is the default behavior of Parse.
+type Options struct {
+	// Dialect enables the SQL extensions of a specific database
+	Dialect Dialect
+	// KeepComments stores the skipped comments in Query.Comments, e.g. for a formatter preserving them
+	KeepComments bool
+	// ValidateNumbers checks that number literals in WHERE are well-formed, e.g. 1.2.3 or 1a are
+	// reported as invalid numbers
+	ValidateNumbers bool
+	// StringQuote is a quote delimiting strings besides the single quote, e.g. '"' for a DSL with double
+	// quoted strings. It must be a single quote, a double quote or a backtick. The zero value keeps the
+	// default of the dialect, see Dialect.DoubleQuotedStrings.
+	StringQuote rune
+	// StrictTrailing reports any token after a complete statement, which doesn't start a valid clause,
+	// as "at end: unexpected token", instead of the error of the next expected clause
+	StrictTrailing bool
+	// AllowTrailingCommas accepts a comma after the last SELECT field, INSERT field and VALUES value,
+	// e.g. SELECT a, b, FROM 'c', as generated by some tools
+	AllowTrailingCommas bool
+	// OnStatement, if not nil, is called by ParseManyWithOptions and ParseScriptWithOptions after each
+	// statement is parsed, with the statement, the parse result and the time spent parsing it
+	OnStatement func(sql string, q query.Query, err error, dur time.Duration)
+}
+
+// FeatureSet is the SQL syntax supported by the parser for a dialect, see Features
+type FeatureSet struct {
+	// Joins is set if JOIN clauses are parsed
+	Joins bool
+	// CTEs is set if WITH common table expressions are parsed
+	CTEs bool
+	// Subqueries is set if nested SELECT queries are parsed in conditions
+	Subqueries bool
+	// DerivedTables is set for a SELECT subquery in FROM, e.g. FROM (SELECT a FROM b) sub
+	DerivedTables bool
+	// WindowFunctions is set if OVER (...) windows of SELECTed functions are parsed
+	WindowFunctions bool
+	// LimitOffset is set for SELECT ... LIMIT n OFFSET m
+	LimitOffset bool
+	// Placeholders is set for prepared statement parameters, ? or $n, as values and LIMIT/OFFSET
+	Placeholders bool
+	// Top is set for SQL Server SELECT TOP n [PERCENT]
+	Top bool
+	// TableStatement is set for the Postgres TABLE table_name shorthand of SELECT * FROM table_name
+	TableStatement bool
+	// Only is set for Postgres FROM ONLY table_name
+	Only bool
+	// UpdateFrom is set for Postgres UPDATE ... FROM, with qualified columns like b.id
+	UpdateFrom bool
+	// DeleteUsing is set for Postgres DELETE ... USING, with qualified columns like b.id
+	DeleteUsing bool
+	// DistinctFrom is set for Postgres IS [NOT] DISTINCT FROM
+	DistinctFrom bool
+	// Casts is set for Postgres type casts of values, e.g. '2021-01-01'::date
+	Casts bool
+	// JSONPath is set for Postgres JSON access chains, e.g. data->'a'
+	JSONPath bool
+	// FullTextMatch is set for MySQL MATCH (...) AGAINST (...)
+	FullTextMatch bool
+	// DoubleQuotedStrings is set if double quotes delimit strings, see Dialect.DoubleQuotedStrings
+	DoubleQuotedStrings bool
+	// Transactions is set for BEGIN, COMMIT, ROLLBACK and SAVEPOINT statements
+	Transactions bool
+	// Explain is set for EXPLAIN statement and DESCRIBE table_name
+	Explain bool
+}
+
+// Features returns the SQL syntax supported by the parser for dialect, e.g. to decide whether a query
+// can be parsed before trying
+func Features(dialect Dialect) FeatureSet {
+	return FeatureSet{
+		DerivedTables:       true,
+		WindowFunctions:     true,
+		LimitOffset:         true,
+		Placeholders:        true,
+		Top:                 dialect == DialectSQLServer,
+		TableStatement:      dialect == DialectPostgres,
+		Only:                dialect == DialectPostgres,
+		UpdateFrom:          dialect == DialectPostgres,
+		DeleteUsing:         dialect == DialectPostgres,
+		DistinctFrom:        dialect == DialectPostgres,
+		Casts:               dialect == DialectPostgres,
+		JSONPath:            dialect == DialectPostgres,
+		FullTextMatch:       dialect == DialectMySQL,
+		DoubleQuotedStrings: dialect.DoubleQuotedStrings(),
+		Transactions:        true,
+		Explain:             true,
+	}
+}
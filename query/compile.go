@@ -0,0 +1,248 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Predicate is a compiled WHERE clause, see CompileConditions. It reports whether row matches, row maps
+// field names to their values.
+type Predicate func(row map[string]interface{}) (bool, error)
+
+// valueFunc returns the value of an operand for a row
+type valueFunc func(row map[string]interface{}) interface{}
+
+// conditionFunc returns whether a condition holds for a row. known is false if the result is unknown, i.e.
+// a NULL value was compared.
+type conditionFunc func(row map[string]interface{}) (result, known bool, err error)
+
+// CompileConditions compiles conds, joined by AND like Query.Conditions, to a predicate which can be run
+// for many rows. Operators and literals are resolved once, so this is faster than interpreting the
+// conditions for every row.
+//
+// Fields, quoted strings and numbers are supported as operands, with the comparison operators, IN, NOT IN,
+// LIKE and NOT LIKE. Numbers are compared as float64, strings byte-wise, a field value must be a string
+// or a Go number. A nil or missing field value is NULL: a condition comparing it doesn't match, negated
+// or not. Other operands, operators, quantifiers and casts fail to compile.
+func CompileConditions(conds []Condition) (Predicate, error) {
+	funcs := make([]conditionFunc, 0, len(conds))
+	for _, c := range conds {
+		f, err := compileCondition(c)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, f)
+	}
+	return func(row map[string]interface{}) (bool, error) {
+		for _, f := range funcs {
+			result, known, err := f(row)
+			if err != nil || !known || !result {
+				return false, err
+			}
+		}
+		return true, nil
+	}, nil
+}
+
+func compileCondition(c Condition) (conditionFunc, error) {
+	if c.Quantifier != NoQuantifier {
+		return nil, fmt.Errorf("unsupported quantifier %s in condition %s", c.Quantifier, c)
+	}
+	if c.Operand1Cast != "" || c.Operand2Cast != "" {
+		return nil, fmt.Errorf("unsupported cast in condition %s", c)
+	}
+	left, err := compileOperand(c.Operand1, c.Operand1Type)
+	if err != nil {
+		return nil, err
+	}
+	var f conditionFunc
+	switch c.Operator {
+	case Eq, Ne, Gt, Lt, Gte, Lte:
+		right, err := compileOperand(c.Operand2, c.Operand2Type)
+		if err != nil {
+			return nil, err
+		}
+		f = compileComparison(c.Operator, left, right)
+	case In, NotIn:
+		if c.Operand2Type != OpList {
+			return nil, fmt.Errorf("expected list after %s in condition %s", c.Operator.Symbol(), c)
+		}
+		list := make([]valueFunc, 0, len(c.Operand2List))
+		for _, op := range c.Operand2List {
+			if op.Cast != "" {
+				return nil, fmt.Errorf("unsupported cast in condition %s", c)
+			}
+			value, err := compileOperand(op.Value, op.Type)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		f = compileIn(c.Operator == NotIn, left, list)
+	case Like, NotLike:
+		pattern, err := LikePattern(Operand{Value: c.Operand2, Type: c.Operand2Type}, c.Escape)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f = compileLike(c.Operator == NotLike, left, re)
+	default:
+		return nil, fmt.Errorf("unsupported operator %s in condition %s", c.Operator, c)
+	}
+	if !c.Not {
+		return f, nil
+	}
+	return func(row map[string]interface{}) (bool, bool, error) {
+		result, known, err := f(row)
+		return !result, known, err
+	}, nil
+}
+
+// compileOperand returns the value of a field from the row, or the constant value of a literal
+func compileOperand(value string, opType OperandType) (valueFunc, error) {
+	var constant interface{}
+	switch opType {
+	case OpField:
+		return func(row map[string]interface{}) interface{} {
+			return row[value]
+		}, nil
+	case OpQuoted:
+		constant = value
+	case OpNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", value)
+		}
+		constant = n
+	default:
+		return nil, fmt.Errorf("unsupported operand %s of type %s", value, opType)
+	}
+	return func(map[string]interface{}) interface{} {
+		return constant
+	}, nil
+}
+
+func compileComparison(op Operator, left, right valueFunc) conditionFunc {
+	return func(row map[string]interface{}) (bool, bool, error) {
+		cmp, known, err := compareValues(row, left, right)
+		if err != nil || !known {
+			return false, false, err
+		}
+		return comparisonHolds(op, cmp), true, nil
+	}
+}
+
+// comparisonHolds checks if the comparison operator op holds for the result of comparing its operands,
+// -1, 0 or 1
+func comparisonHolds(op Operator, cmp int) bool {
+	switch op {
+	case Eq:
+		return cmp == 0
+	case Ne:
+		return cmp != 0
+	case Gt:
+		return cmp > 0
+	case Lt:
+		return cmp < 0
+	case Gte:
+		return cmp >= 0
+	}
+	return cmp <= 0
+}
+
+func compileIn(not bool, left valueFunc, list []valueFunc) conditionFunc {
+	return func(row map[string]interface{}) (bool, bool, error) {
+		// a NULL in the list makes a non-matching IN unknown
+		known := true
+		for _, value := range list {
+			cmp, ok, err := compareValues(row, left, value)
+			if err != nil {
+				return false, false, err
+			}
+			if ok && cmp == 0 {
+				return !not, true, nil
+			}
+			known = known && ok
+		}
+		return not, known, nil
+	}
+}
+
+func compileLike(not bool, left valueFunc, re *regexp.Regexp) conditionFunc {
+	return func(row map[string]interface{}) (bool, bool, error) {
+		v := left(row)
+		if v == nil {
+			return false, false, nil
+		}
+		s, ok := v.(string)
+		if !ok {
+			return false, false, fmt.Errorf("LIKE expects a string, got %T", v)
+		}
+		return re.MatchString(s) != not, true, nil
+	}
+}
+
+// compareValues compares the values of both operands, -1, 0 or 1. known is false if either is NULL.
+func compareValues(row map[string]interface{}, left, right valueFunc) (cmp int, known bool, err error) {
+	a, b := left(row), right(row)
+	if a == nil || b == nil {
+		return 0, false, nil
+	}
+	if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		if !ok {
+			return 0, false, fmt.Errorf("cannot compare string with %T", b)
+		}
+		return strings.Compare(x, y), true, nil
+	}
+	x, ok := toFloat(a)
+	if !ok {
+		return 0, false, fmt.Errorf("unsupported value of type %T", a)
+	}
+	y, ok := toFloat(b)
+	if !ok {
+		return 0, false, fmt.Errorf("cannot compare number with %T", b)
+	}
+	switch {
+	case x < y:
+		return -1, true, nil
+	case x > y:
+		return 1, true, nil
+	}
+	return 0, true, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,172 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Debug returns a human readable outline of the query, with the operand types hidden by String(),
+// e.g. to see how a SQL query was interpreted:
+//
+//	Select
+//	  TableName: b
+//	  Fields:
+//	    a AS c
+//	  Conditions:
+//	    - Eq
+//	      Operand1: a (OpField)
+//	      Operand2: 1 (OpQuoted)
+func (q Query) Debug() string {
+	var sb strings.Builder
+	sb.WriteString(enumString(TypeString, int(q.Type)))
+	sb.WriteByte('\n')
+	if q.Explain {
+		sb.WriteString("  Explain\n")
+	}
+	if q.TableName != "" {
+		fmt.Fprintf(&sb, "  TableName: %s\n", q.TableName)
+	}
+	if q.OnlyTable {
+		sb.WriteString("  OnlyTable\n")
+	}
+	if q.FromQuery != nil {
+		fmt.Fprintf(&sb, "  FromQuery: %s AS %s\n", q.FromQuery, q.FromAlias)
+	}
+	if q.Distinct {
+		sb.WriteString("  Distinct\n")
+	}
+	if q.Limit != nil {
+		fmt.Fprintf(&sb, "  Limit: %d", *q.Limit)
+		if q.LimitPercent {
+			sb.WriteString(" PERCENT")
+		}
+		sb.WriteByte('\n')
+	}
+	if q.LimitParam != nil {
+		fmt.Fprintf(&sb, "  Limit: %s\n", q.LimitParam.Value)
+	}
+	if q.Offset != nil {
+		fmt.Fprintf(&sb, "  Offset: %d\n", *q.Offset)
+	}
+	if q.OffsetParam != nil {
+		fmt.Fprintf(&sb, "  Offset: %s\n", q.OffsetParam.Value)
+	}
+	if len(q.Fields) > 0 {
+		sb.WriteString("  Fields:\n")
+		for i, field := range q.Fields {
+			fmt.Fprintf(&sb, "    %s", field)
+			if i < len(q.Aliases) && q.Aliases[i] != "" {
+				fmt.Fprintf(&sb, " AS %s", q.Aliases[i])
+			}
+			sb.WriteByte('\n')
+		}
+	}
+	if len(q.Updates) > 0 {
+		sb.WriteString("  Updates:\n")
+		fields := make([]string, 0, len(q.Updates))
+		for field := range q.Updates {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fmt.Fprintf(&sb, "    %s = ", field)
+			debugOperand(&sb, Operand{Value: q.Updates[field], Type: q.UpdateType(field), Cast: q.UpdateCasts[field]})
+			sb.WriteByte('\n')
+		}
+	}
+	if len(q.UpdateFrom) > 0 {
+		fmt.Fprintf(&sb, "  UpdateFrom: %s\n", strings.Join(q.UpdateFrom, ", "))
+	}
+	if len(q.DeleteUsing) > 0 {
+		fmt.Fprintf(&sb, "  DeleteUsing: %s\n", strings.Join(q.DeleteUsing, ", "))
+	}
+	if len(q.Inserts) > 0 {
+		sb.WriteString("  Inserts:\n")
+		for _, row := range q.Inserts {
+			fmt.Fprintf(&sb, "    %q\n", row)
+		}
+	}
+	if len(q.AlterActions) > 0 {
+		sb.WriteString("  AlterActions:\n")
+		for _, a := range q.AlterActions {
+			fmt.Fprintf(&sb, "    %s %s", enumString(AlterActionString, int(a.Action)), a.Column)
+			if a.ColumnType != "" {
+				fmt.Fprintf(&sb, " %s", a.ColumnType)
+			}
+			sb.WriteByte('\n')
+		}
+	}
+	if q.SavepointName != "" {
+		fmt.Fprintf(&sb, "  SavepointName: %s\n", q.SavepointName)
+	}
+	if len(q.Conditions) > 0 {
+		sb.WriteString("  Conditions:\n")
+		for _, c := range q.Conditions {
+			sb.WriteString("    - ")
+			if c.Not {
+				sb.WriteString("Not ")
+			}
+			sb.WriteString(enumString(OperatorString, int(c.Operator)))
+			if c.Quantifier != NoQuantifier {
+				fmt.Fprintf(&sb, " %s", enumString(QuantifierString, int(c.Quantifier)))
+			}
+			sb.WriteString("\n      Operand1: ")
+			if c.Operand1Type == OpList {
+				debugOperands(&sb, c.Operand1List)
+				sb.WriteString(" (OpList)")
+			} else {
+				debugOperand(&sb, Operand{Value: c.Operand1, Type: c.Operand1Type, Tuple: c.Operand1List, Cast: c.Operand1Cast})
+			}
+			sb.WriteString("\n      Operand2: ")
+			if c.Operand2Type == OpList {
+				debugOperands(&sb, c.Operand2List)
+				sb.WriteString(" (OpList)")
+			} else {
+				debugOperand(&sb, Operand{Value: c.Operand2, Type: c.Operand2Type, Cast: c.Operand2Cast})
+			}
+			if c.Escape != "" {
+				fmt.Fprintf(&sb, "\n      Escape: %s", c.Escape)
+			}
+			if c.Collation != "" {
+				fmt.Fprintf(&sb, "\n      Collation: %s", c.Collation)
+			}
+			if c.MatchMode != "" {
+				fmt.Fprintf(&sb, "\n      MatchMode: %s", c.MatchMode)
+			}
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func debugOperand(sb *strings.Builder, op Operand) {
+	if op.Type == OpTuple {
+		debugOperands(sb, op.Tuple)
+	} else {
+		sb.WriteString(op.Value)
+	}
+	if op.Cast != "" {
+		fmt.Fprintf(sb, "::%s", op.Cast)
+	}
+	fmt.Fprintf(sb, " (%s)", enumString(OperandTypeString, int(op.Type)))
+}
+
+func debugOperands(sb *strings.Builder, ops []Operand) {
+	sb.WriteByte('[')
+	for i, op := range ops {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		debugOperand(sb, op)
+	}
+	sb.WriteByte(']')
+}
+
+// enumString returns the name of v in names, or the number if it's out of range
+func enumString(names []string, v int) string {
+	if v >= 0 && v < len(names) {
+		return names[v]
+	}
+	return fmt.Sprintf("%d", v)
+}
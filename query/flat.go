@@ -0,0 +1,315 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FlatQuery is a Query without maps, pointers and nested slices, e.g. as a serialization target for a
+// protobuf schema. Enums are stored by name, so the serialized form doesn't depend on their order.
+type FlatQuery struct {
+	Type      string
+	TableName string
+	OnlyTable bool
+	// FromQuery is the subquery of a derived table, with one element if set
+	FromQuery    []FlatQuery
+	FromAlias    string
+	Explain      bool
+	Conditions   []FlatCondition
+	Updates      []FlatUpdate // sorted by field
+	UpdateFrom   []string
+	DeleteUsing  []string
+	Inserts      []FlatRow
+	Fields       []string
+	Aliases      []string
+	Windows      []Window
+	Distinct     bool
+	HasLimit     bool
+	Limit        int64
+	LimitPercent bool
+	HasOffset    bool
+	Offset       int64
+	// LimitParam and OffsetParam are the placeholders of LIMIT and OFFSET, empty if not set
+	LimitParam    string
+	OffsetParam   string
+	AlterActions  []FlatAlterAction
+	SavepointName string
+	Comments      []Comment
+}
+
+// FlatOperand is an operand with its type name as Kind, e.g. {OpQuoted, 1}. The elements of an OpList
+// or OpTuple operand are in Elements.
+type FlatOperand struct {
+	Kind     string
+	Text     string
+	Elements []FlatOperand
+	// Cast is the type of a Postgres cast of the operand, see Operand.Cast
+	Cast string
+}
+
+// FlatCondition is a Condition with both sides as a FlatOperand
+type FlatCondition struct {
+	Operand1   FlatOperand
+	Operator   string
+	Operand2   FlatOperand
+	Quantifier string
+	Escape     string
+	Collation  string
+	Not        bool
+	MatchMode  string
+}
+
+// FlatUpdate is a single assignment of an UPDATE query, e.g. a = '1'
+type FlatUpdate struct {
+	Field string
+	Value FlatOperand
+}
+
+// FlatRow is a single row of INSERT values
+type FlatRow struct {
+	Values []string
+}
+
+// FlatAlterAction is an AlterAction with the action name, e.g. AddColumn
+type FlatAlterAction struct {
+	Action     string
+	Column     string
+	ColumnType string
+}
+
+// Flatten returns the query as a FlatQuery. FlatQuery.Unflatten returns an equal Query.
+// Slices like Fields are shared with q.
+func (q Query) Flatten() FlatQuery {
+	f := FlatQuery{
+		Type:          enumString(TypeString, int(q.Type)),
+		TableName:     q.TableName,
+		OnlyTable:     q.OnlyTable,
+		FromAlias:     q.FromAlias,
+		Explain:       q.Explain,
+		Fields:        q.Fields,
+		Aliases:       q.Aliases,
+		UpdateFrom:    q.UpdateFrom,
+		DeleteUsing:   q.DeleteUsing,
+		Windows:       q.Windows,
+		Distinct:      q.Distinct,
+		LimitPercent:  q.LimitPercent,
+		SavepointName: q.SavepointName,
+		Comments:      q.Comments,
+	}
+	if q.FromQuery != nil {
+		f.FromQuery = []FlatQuery{q.FromQuery.Flatten()}
+	}
+	if q.Limit != nil {
+		f.HasLimit = true
+		f.Limit = *q.Limit
+	}
+	if q.Offset != nil {
+		f.HasOffset = true
+		f.Offset = *q.Offset
+	}
+	if q.LimitParam != nil {
+		f.LimitParam = q.LimitParam.Value
+	}
+	if q.OffsetParam != nil {
+		f.OffsetParam = q.OffsetParam.Value
+	}
+	for _, c := range q.Conditions {
+		fc := FlatCondition{
+			Operator:   enumString(OperatorString, int(c.Operator)),
+			Quantifier: enumString(QuantifierString, int(c.Quantifier)),
+			Escape:     c.Escape,
+			Collation:  c.Collation,
+			Not:        c.Not,
+			MatchMode:  c.MatchMode,
+		}
+		if c.Operand1Type == OpTuple || c.Operand1Type == OpList {
+			fc.Operand1 = flattenList(c.Operand1Type, c.Operand1List)
+		} else {
+			fc.Operand1 = flattenOperand(Operand{Value: c.Operand1, Type: c.Operand1Type, Cast: c.Operand1Cast})
+		}
+		if c.Operand2Type == OpList {
+			fc.Operand2 = flattenList(c.Operand2Type, c.Operand2List)
+		} else {
+			fc.Operand2 = flattenOperand(Operand{Value: c.Operand2, Type: c.Operand2Type, Cast: c.Operand2Cast})
+		}
+		f.Conditions = append(f.Conditions, fc)
+	}
+	// Updates is a map, sort for a stable output
+	fields := make([]string, 0, len(q.Updates))
+	for field := range q.Updates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		value := flattenOperand(Operand{Value: q.Updates[field], Type: q.UpdateType(field), Cast: q.UpdateCasts[field]})
+		f.Updates = append(f.Updates, FlatUpdate{Field: field, Value: value})
+	}
+	for _, row := range q.Inserts {
+		f.Inserts = append(f.Inserts, FlatRow{Values: row})
+	}
+	for _, a := range q.AlterActions {
+		f.AlterActions = append(f.AlterActions, FlatAlterAction{
+			Action:     enumString(AlterActionString, int(a.Action)),
+			Column:     a.Column,
+			ColumnType: a.ColumnType,
+		})
+	}
+	return f
+}
+
+func flattenOperand(op Operand) FlatOperand {
+	if op.Type == OpTuple {
+		return flattenList(op.Type, op.Tuple)
+	}
+	return FlatOperand{Kind: enumString(OperandTypeString, int(op.Type)), Text: op.Value, Cast: op.Cast}
+}
+
+func flattenList(opType OperandType, ops []Operand) FlatOperand {
+	f := FlatOperand{Kind: enumString(OperandTypeString, int(opType))}
+	for _, op := range ops {
+		f.Elements = append(f.Elements, flattenOperand(op))
+	}
+	return f
+}
+
+// Unflatten returns the Query of f. It fails if an enum name is unknown. Slices like Fields are shared
+// with f.
+func (f FlatQuery) Unflatten() (Query, error) {
+	q := Query{
+		TableName:     f.TableName,
+		OnlyTable:     f.OnlyTable,
+		FromAlias:     f.FromAlias,
+		Explain:       f.Explain,
+		Fields:        f.Fields,
+		Aliases:       f.Aliases,
+		UpdateFrom:    f.UpdateFrom,
+		DeleteUsing:   f.DeleteUsing,
+		Windows:       f.Windows,
+		Distinct:      f.Distinct,
+		LimitPercent:  f.LimitPercent,
+		SavepointName: f.SavepointName,
+		Comments:      f.Comments,
+	}
+	typ, err := enumValue(TypeString, f.Type, "query type")
+	if err != nil {
+		return Query{}, err
+	}
+	q.Type = Type(typ)
+	if len(f.FromQuery) > 0 {
+		from, err := f.FromQuery[0].Unflatten()
+		if err != nil {
+			return Query{}, err
+		}
+		q.FromQuery = &from
+	}
+	if f.HasLimit {
+		limit := f.Limit
+		q.Limit = &limit
+	}
+	if f.HasOffset {
+		offset := f.Offset
+		q.Offset = &offset
+	}
+	if f.LimitParam != "" {
+		q.LimitParam = &Operand{Value: f.LimitParam, Type: OpPlaceholder}
+	}
+	if f.OffsetParam != "" {
+		q.OffsetParam = &Operand{Value: f.OffsetParam, Type: OpPlaceholder}
+	}
+	for _, fc := range f.Conditions {
+		c := Condition{Escape: fc.Escape, Collation: fc.Collation, Not: fc.Not, MatchMode: fc.MatchMode}
+		operator, err := enumValue(OperatorString, fc.Operator, "operator")
+		if err != nil {
+			return Query{}, err
+		}
+		c.Operator = Operator(operator)
+		quantifier, err := enumValue(QuantifierString, fc.Quantifier, "quantifier")
+		if err != nil {
+			return Query{}, err
+		}
+		c.Quantifier = Quantifier(quantifier)
+		op1, err := unflattenOperand(fc.Operand1)
+		if err != nil {
+			return Query{}, err
+		}
+		if op1.Type == OpTuple || op1.Type == OpList {
+			c.Operand1Type, c.Operand1List = op1.Type, op1.Tuple
+		} else {
+			c.Operand1, c.Operand1Type, c.Operand1Cast = op1.Value, op1.Type, op1.Cast
+		}
+		op2, err := unflattenOperand(fc.Operand2)
+		if err != nil {
+			return Query{}, err
+		}
+		if op2.Type == OpList {
+			c.Operand2Type, c.Operand2List = op2.Type, op2.Tuple
+		} else {
+			c.Operand2, c.Operand2Type, c.Operand2Cast = op2.Value, op2.Type, op2.Cast
+		}
+		q.Conditions = append(q.Conditions, c)
+	}
+	if q.Type == Update || len(f.Updates) > 0 {
+		q.Updates = make(map[string]string, len(f.Updates))
+	}
+	for _, u := range f.Updates {
+		value, err := unflattenOperand(u.Value)
+		if err != nil {
+			return Query{}, err
+		}
+		q.Updates[u.Field] = value.Value
+		if value.Type != OpQuoted {
+			if q.UpdateTypes == nil {
+				q.UpdateTypes = map[string]OperandType{}
+			}
+			q.UpdateTypes[u.Field] = value.Type
+		}
+		if value.Cast != "" {
+			if q.UpdateCasts == nil {
+				q.UpdateCasts = map[string]string{}
+			}
+			q.UpdateCasts[u.Field] = value.Cast
+		}
+	}
+	for _, row := range f.Inserts {
+		q.Inserts = append(q.Inserts, row.Values)
+	}
+	for _, fa := range f.AlterActions {
+		action, err := enumValue(AlterActionString, fa.Action, "alter action")
+		if err != nil {
+			return Query{}, err
+		}
+		q.AlterActions = append(q.AlterActions, AlterAction{
+			Action:     AlterActionType(action),
+			Column:     fa.Column,
+			ColumnType: fa.ColumnType,
+		})
+	}
+	return q, nil
+}
+
+// unflattenOperand returns the operand of f, the elements of a list are returned in Tuple
+func unflattenOperand(f FlatOperand) (Operand, error) {
+	opType, err := enumValue(OperandTypeString, f.Kind, "operand kind")
+	if err != nil {
+		return Operand{}, err
+	}
+	op := Operand{Value: f.Text, Type: OperandType(opType), Cast: f.Cast}
+	for _, e := range f.Elements {
+		element, err := unflattenOperand(e)
+		if err != nil {
+			return Operand{}, err
+		}
+		op.Tuple = append(op.Tuple, element)
+	}
+	return op, nil
+}
+
+// enumValue returns the index of name in names, what is the enum kind for the error message
+func enumValue(names []string, name, what string) (int, error) {
+	for i, n := range names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s '%s'", what, name)
+}
@@ -0,0 +1,130 @@
+package query
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"sort"
+)
+
+// Hash returns a hash of the query structure, e.g. as a cache key. Queries parsed from the same SQL
+// have the same hash, regardless of whitespace, comments and keyword case. Numbers are hashed in their
+// canonical form, so 1.0 and 1 have the same hash.
+func (q Query) Hash() uint64 {
+	h := hasher{fnv.New64a()}
+	h.int(int64(q.Type))
+	h.bool(q.Explain)
+	h.string(q.TableName)
+	h.bool(q.OnlyTable)
+	if q.FromQuery != nil {
+		h.bool(true)
+		h.int(int64(q.FromQuery.Hash()))
+		h.string(q.FromAlias)
+	} else {
+		h.bool(false)
+	}
+	h.bool(q.Distinct)
+	if q.Limit != nil {
+		h.bool(true)
+		h.int(*q.Limit)
+	} else {
+		h.bool(false)
+	}
+	h.bool(q.LimitPercent)
+	if q.Offset != nil {
+		h.bool(true)
+		h.int(*q.Offset)
+	} else {
+		h.bool(false)
+	}
+	h.params(q.LimitParam)
+	h.params(q.OffsetParam)
+	h.strings(q.Fields)
+	h.strings(q.Aliases)
+	h.int(int64(len(q.Conditions)))
+	for _, c := range q.Conditions {
+		h.operands([]Operand{{Value: c.Operand1, Type: c.Operand1Type, Tuple: c.Operand1List, Cast: c.Operand1Cast}})
+		h.int(int64(c.Operator))
+		h.operands([]Operand{{Value: c.Operand2, Type: c.Operand2Type, Cast: c.Operand2Cast}})
+		h.operands(c.Operand2List)
+		h.int(int64(c.Quantifier))
+		h.string(c.Escape)
+		h.string(c.Collation)
+		h.bool(c.Not)
+		h.string(c.MatchMode)
+	}
+	// Updates is a map, sort for a stable hash
+	fields := make([]string, 0, len(q.Updates))
+	for field := range q.Updates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	h.int(int64(len(fields)))
+	for _, field := range fields {
+		h.string(field)
+		h.operands([]Operand{{Value: q.Updates[field], Type: q.UpdateType(field), Cast: q.UpdateCasts[field]}})
+	}
+	h.strings(q.UpdateFrom)
+	h.strings(q.DeleteUsing)
+	h.int(int64(len(q.Inserts)))
+	for _, row := range q.Inserts {
+		h.strings(row)
+	}
+	h.int(int64(len(q.AlterActions)))
+	for _, a := range q.AlterActions {
+		h.int(int64(a.Action))
+		h.string(a.Column)
+		h.string(a.ColumnType)
+	}
+	h.string(q.SavepointName)
+	return h.Sum64()
+}
+
+// hasher writes values to a hash, prefixed by their length, so different values can't have the same input
+type hasher struct {
+	hash.Hash64
+}
+
+func (h hasher) int(v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	h.Write(buf[:binary.PutVarint(buf[:], v)])
+}
+
+func (h hasher) bool(v bool) {
+	if v {
+		h.int(1)
+	} else {
+		h.int(0)
+	}
+}
+
+func (h hasher) string(s string) {
+	h.int(int64(len(s)))
+	h.Write([]byte(s))
+}
+
+func (h hasher) strings(s []string) {
+	h.int(int64(len(s)))
+	for _, v := range s {
+		h.string(v)
+	}
+}
+
+// params writes an optional placeholder, e.g. Query.LimitParam
+func (h hasher) params(op *Operand) {
+	if op == nil {
+		h.operands(nil)
+	} else {
+		h.operands([]Operand{*op})
+	}
+}
+
+func (h hasher) operands(ops []Operand) {
+	h.int(int64(len(ops)))
+	for _, op := range ops {
+		h.int(int64(op.Type))
+		h.string(op.Canonical())
+		h.operands(op.Tuple)
+		h.string(op.Cast)
+	}
+}
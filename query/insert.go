@@ -0,0 +1,53 @@
+package query
+
+import "fmt"
+
+// InsertBuilder builds an INSERT query row by row, see NewInsert
+type InsertBuilder struct {
+	table  string
+	fields []string
+	rows   [][]Operand
+}
+
+// NewInsert returns a builder of an INSERT query of fields into table. Rows are added with AddRow.
+func NewInsert(table string, fields ...string) *InsertBuilder {
+	return &InsertBuilder{table: table, fields: fields}
+}
+
+// AddRow adds a row of values, one per field, and returns b for chaining. Values must be OpQuoted
+// operands with the raw text, like the parser returns them, e.g. it's. They are escaped by Query.String.
+func (b *InsertBuilder) AddRow(values ...Operand) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Build returns the INSERT query as the parser returns it. It fails if there are no fields or rows, if a
+// row doesn't have one value per field or if a value isn't quoted.
+func (b *InsertBuilder) Build() (Query, error) {
+	if len(b.fields) == 0 {
+		return Query{}, fmt.Errorf("INSERT into '%s' needs at least one field", b.table)
+	}
+	if len(b.rows) == 0 {
+		return Query{}, fmt.Errorf("INSERT into '%s' needs at least one row", b.table)
+	}
+	q := Query{
+		Type:      Insert,
+		TableName: b.table,
+		Fields:    append([]string(nil), b.fields...),
+		Inserts:   make([][]string, 0, len(b.rows)),
+	}
+	for i, row := range b.rows {
+		if len(row) != len(b.fields) {
+			return Query{}, fmt.Errorf("row %d has %d values, expected %d", i+1, len(row), len(b.fields))
+		}
+		values := make([]string, 0, len(row))
+		for j, op := range row {
+			if op.Type != OpQuoted {
+				return Query{}, fmt.Errorf("row %d: value %d must be quoted, not %s", i+1, j+1, op.Type)
+			}
+			values = append(values, op.Value)
+		}
+		q.Inserts = append(q.Inserts, values)
+	}
+	return q, nil
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// LikePattern converts the pattern of a LIKE condition to a Go regexp matching the whole value, e.g. a%b_
+// to (?s)^a.*b.$. % matches any sequence of characters and _ a single character. If escape is set, it's
+// the ESCAPE character of the condition, see Condition.Escape, and it must be followed by %, _ or itself
+// to match that character literally. op must be an OpQuoted operand.
+func LikePattern(op Operand, escape string) (string, error) {
+	if op.Type != OpQuoted {
+		return "", fmt.Errorf("LIKE pattern must be a quoted string, not %s", op.Type)
+	}
+	if utf8.RuneCountInString(escape) > 1 {
+		return "", fmt.Errorf("LIKE escape must be a single character, not '%s'", escape)
+	}
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	pattern := op.Value
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		if escape != "" && strings.HasPrefix(pattern[i:], escape) {
+			next, nextSize := utf8.DecodeRuneInString(pattern[i+size:])
+			if nextSize == 0 {
+				return "", fmt.Errorf("LIKE pattern ends with the escape character '%s'", escape)
+			}
+			if next != '%' && next != '_' && string(next) != escape {
+				return "", fmt.Errorf("LIKE pattern has an invalid escape sequence at %d", i)
+			}
+			sb.WriteString(regexp.QuoteMeta(string(next)))
+			i += size + nextSize
+			continue
+		}
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteByte('.')
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+size]))
+		}
+		i += size
+	}
+	sb.WriteByte('$')
+	return sb.String(), nil
+}
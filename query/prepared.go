@@ -0,0 +1,37 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PlaceholderStyle is the syntax of the placeholders written by Query.ToPrepared
+type PlaceholderStyle int
+
+const (
+	// PlaceholderQuestion writes ? for every parameter, e.g. for MySQL and SQLite
+	PlaceholderQuestion PlaceholderStyle = iota
+	// PlaceholderDollar writes numbered parameters $1, $2, ..., e.g. for Postgres
+	PlaceholderDollar
+)
+
+// ToPrepared returns the SQL representation of the query, like String, with each quoted string, number,
+// hex and bit literal replaced by a placeholder in style. The replaced literals are returned as args, in
+// the order of their placeholders. Intervals are kept as is. The placeholders already in the query
+// are kept too, so args only bind all parameters of a query without placeholders.
+func (q Query) ToPrepared(style PlaceholderStyle) (string, []Operand) {
+	args := []Operand{}
+	sql := formatter{parserSyntax: true, args: &args, style: style}.query(q)
+	return sql, args
+}
+
+// bind appends the literal value to the args and writes its placeholder
+func (f formatter) bind(sb *strings.Builder, value string, opType OperandType) {
+	*f.args = append(*f.args, Operand{Value: value, Type: opType})
+	if f.style == PlaceholderDollar {
+		sb.WriteByte('$')
+		sb.WriteString(strconv.Itoa(len(*f.args)))
+	} else {
+		sb.WriteByte('?')
+	}
+}
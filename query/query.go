@@ -1,14 +1,89 @@
 package query
 
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Query represents a parsed query
 type Query struct {
-	Type       Type
-	TableName  string
+	Type      Type
+	TableName string
+	// OnlyTable is set if the table is used without its inheriting tables, i.e. FROM ONLY table_name
+	OnlyTable bool
+	// FromQuery is the subquery of a derived table, i.e. SELECT ... FROM (SELECT ...) alias, with the
+	// alias in FromAlias. TableName is empty then.
+	FromQuery  *Query
+	FromAlias  string
 	Conditions []Condition
 	Updates    map[string]string
-	Inserts    [][]string
-	Fields     []string // Used for SELECT (i.e. SELECTed field names) and INSERT (INSERTEDed field names)
-	Aliases    []string // Used for SELECT (i.e. SELECTed field_name AS alias_name)
+	// UpdateTypes is the type of the Updates values, which aren't quoted strings, e.g. OpField for
+	// SET a = b. It's nil if all values are quoted.
+	UpdateTypes map[string]OperandType
+	// UpdateCasts are the types of the Postgres casts of the Updates values, e.g. int for SET a = '1'::int.
+	// It's nil if no value is cast.
+	UpdateCasts map[string]string
+	// UpdateFrom are the tables of UPDATE ... FROM, which the SET values and the conditions can refer
+	// to, e.g. UPDATE a SET x = b.y FROM b WHERE a.id = b.id. Postgres only.
+	UpdateFrom []string
+	// DeleteUsing are the tables of DELETE ... USING, which the conditions can refer to, e.g.
+	// DELETE FROM a USING b WHERE a.id = b.id. Postgres only.
+	DeleteUsing []string
+	Inserts     [][]string
+	Fields      []string // Used for SELECT (i.e. SELECTed field names) and INSERT (INSERTEDed field names)
+	Aliases     []string // Used for SELECT (i.e. SELECTed field_name AS alias_name)
+	// Windows are the windows of the SELECTed window functions, e.g. row_number() OVER (ORDER BY a)
+	Windows []Window
+	// Distinct is set for SELECT DISTINCT
+	Distinct bool
+	// Limit is the maximum number of rows to SELECT, nil if not limited
+	Limit *int64
+	// Offset is the number of rows to skip before the rows to SELECT, nil if not set
+	Offset *int64
+	// LimitParam is the OpPlaceholder operand of LIMIT ?, nil if the limit isn't a placeholder
+	LimitParam *Operand
+	// OffsetParam is the OpPlaceholder operand of OFFSET ?, nil if the offset isn't a placeholder
+	OffsetParam *Operand
+	// LimitPercent is set if Limit is a percentage of the rows, i.e. SELECT TOP 10 PERCENT
+	LimitPercent bool
+	// AlterActions is used for ALTER TABLE (i.e. ADD COLUMN field_name field_type)
+	AlterActions []AlterAction
+	// SavepointName is the name of the savepoint for SAVEPOINT
+	SavepointName string
+	// Explain is set if the statement is prefixed by EXPLAIN, e.g. EXPLAIN SELECT a FROM b
+	Explain bool
+	// Comments are the comments found in the query, only stored if the parser was asked to keep them
+	Comments []Comment
+}
+
+// Window is the OVER clause of a window function in the SELECTed fields. The field text includes the
+// OVER clause, e.g. row_number() OVER (PARTITION BY a ORDER BY b).
+type Window struct {
+	// Field is the index of the window function in Fields
+	Field int
+	// PartitionBy is the PARTITION BY specification as written, e.g. a, b. Empty if there is none.
+	PartitionBy string
+	// OrderBy is the ORDER BY specification as written, with the frame clause if any, e.g. b DESC.
+	// Empty if there is none.
+	OrderBy string
+}
+
+// Comment is a comment found in a query, with its delimiters, e.g. -- text or /* text */
+type Comment struct {
+	Text string
+	// Pos is the byte offset of the comment in the parsed SQL
+	Pos int
+}
+
+// Span is the position of a statement in a script, as byte offsets of script[Start:End]
+type Span struct {
+	Start int
+	End   int
 }
 
 // Type is the type of SQL query, e.g. SELECT/UPDATE
@@ -25,8 +100,23 @@ const (
 	Insert
 	// Delete represents a DELETE query
 	Delete
+	// Alter represents an ALTER TABLE query
+	Alter
+	// Begin represents a BEGIN [TRANSACTION] statement
+	Begin
+	// Commit represents a COMMIT [TRANSACTION] statement
+	Commit
+	// Rollback represents a ROLLBACK [TRANSACTION] statement
+	Rollback
+	// Savepoint represents a SAVEPOINT name statement
+	Savepoint
+	// Describe represents a DESCRIBE table_name statement
+	Describe
 )
 
+// ErrEmptyType is returned by Validate for a query without a query type
+var ErrEmptyType = errors.New("query type cannot be empty")
+
 // TypeString is a string slice with the names of all types in order
 var TypeString = []string{
 	"UnknownType",
@@ -34,6 +124,17 @@ var TypeString = []string{
 	"Update",
 	"Insert",
 	"Delete",
+	"Alter",
+	"Begin",
+	"Commit",
+	"Rollback",
+	"Savepoint",
+	"Describe",
+}
+
+// String returns the name of the query type, e.g. Select. It returns the number for an undefined value.
+func (t Type) String() string {
+	return enumString(TypeString, int(t))
 }
 
 // Operator is between operands in a condition
@@ -54,6 +155,20 @@ const (
 	Gte
 	// Lte -> "<="
 	Lte
+	// IsDistinctFrom -> "IS DISTINCT FROM"
+	IsDistinctFrom
+	// IsNotDistinctFrom -> "IS NOT DISTINCT FROM"
+	IsNotDistinctFrom
+	// In -> "IN"
+	In
+	// NotIn -> "NOT IN"
+	NotIn
+	// Like -> "LIKE"
+	Like
+	// NotLike -> "NOT LIKE"
+	NotLike
+	// Match -> "MATCH (...) AGAINST", a MySQL full-text search
+	Match
 )
 
 // OperatorString is a string slice with the names of all operators in order
@@ -65,6 +180,92 @@ var OperatorString = []string{
 	"Lt",
 	"Gte",
 	"Lte",
+	"IsDistinctFrom",
+	"IsNotDistinctFrom",
+	"In",
+	"NotIn",
+	"Like",
+	"NotLike",
+	"Match",
+}
+
+// String returns the name of the operator, e.g. Eq. It returns the number for an undefined value.
+func (o Operator) String() string {
+	return enumString(OperatorString, int(o))
+}
+
+var operatorSymbol = []string{
+	"",
+	"=",
+	"!=",
+	">",
+	"<",
+	">=",
+	"<=",
+	"IS DISTINCT FROM",
+	"IS NOT DISTINCT FROM",
+	"IN",
+	"NOT IN",
+	"LIKE",
+	"NOT LIKE",
+	"AGAINST",
+}
+
+// Symbol returns the SQL representation of the operator, e.g. "=" for Eq.
+// It returns an empty string for UnknownOperator and out of range values.
+func (o Operator) Symbol() string {
+	if o < 0 || int(o) >= len(operatorSymbol) {
+		return ""
+	}
+	return operatorSymbol[o]
+}
+
+// negatedOperators maps each operator to its logical negation, both ways
+var negatedOperators = map[Operator]Operator{
+	Eq:                Ne,
+	Ne:                Eq,
+	Gt:                Lte,
+	Lte:               Gt,
+	Lt:                Gte,
+	Gte:               Lt,
+	IsDistinctFrom:    IsNotDistinctFrom,
+	IsNotDistinctFrom: IsDistinctFrom,
+	In:                NotIn,
+	NotIn:             In,
+	Like:              NotLike,
+	NotLike:           Like,
+}
+
+// Negate returns the operator of the negated condition, e.g. Lte for Gt, as NOT (a > b) is a <= b.
+// It returns false if there is no such operator, e.g. for Match. With a quantifier, the quantifier
+// must be swapped too, NOT (a = ANY (...)) is a != ALL (...).
+func (o Operator) Negate() (Operator, bool) {
+	negated, ok := negatedOperators[o]
+	return negated, ok
+}
+
+// Quantifier is applied to a comparison with a list, e.g. a > ANY ('1', '2')
+type Quantifier int
+
+const (
+	// NoQuantifier is the zero value for a Quantifier
+	NoQuantifier Quantifier = iota
+	// Any -> "ANY"
+	Any
+	// All -> "ALL"
+	All
+)
+
+// QuantifierString is a string slice with the names of all quantifiers in order
+var QuantifierString = []string{
+	"NoQuantifier",
+	"Any",
+	"All",
+}
+
+// String returns the name of the quantifier, e.g. Any. It returns the number for an undefined value.
+func (q Quantifier) String() string {
+	return enumString(QuantifierString, int(q))
 }
 
 type OperandType int
@@ -74,18 +275,929 @@ const (
 	OpField
 	OpQuoted
 	OpNumber
+	OpList
+	OpJSONPath
+	OpFunc
+	OpTuple
+	// OpInterval is an interval literal, stored verbatim, e.g. INTERVAL '1' DAY
+	OpInterval
+	// OpHex is a hexadecimal string literal, stored verbatim, e.g. x'1F'
+	OpHex
+	// OpBit is a bit string literal, stored verbatim, e.g. b'1010'
+	OpBit
+	// OpPlaceholder is a prepared statement parameter, e.g. ? or $1
+	OpPlaceholder
+	// OpExpr is an arithmetic expression in a condition, stored verbatim, e.g. (a + b) or now() - INTERVAL '1 day'
+	OpExpr
+	// OpNull is the NULL value of an UPDATE, stored as NULL
+	OpNull
+	// OpBool is a boolean value of an UPDATE, stored upper cased, e.g. TRUE
+	OpBool
 )
 
+// OperandTypeString is a string slice with the names of all operand types in order
+var OperandTypeString = []string{
+	"OpUnknown",
+	"OpField",
+	"OpQuoted",
+	"OpNumber",
+	"OpList",
+	"OpJSONPath",
+	"OpFunc",
+	"OpTuple",
+	"OpInterval",
+	"OpHex",
+	"OpBit",
+	"OpPlaceholder",
+	"OpExpr",
+	"OpNull",
+	"OpBool",
+}
+
+// String returns the name of the operand type, e.g. OpField. It returns the number for an undefined value.
+func (o OperandType) String() string {
+	return enumString(OperandTypeString, int(o))
+}
+
+// Operand is a single value with its type, e.g. an element of an IN list
+type Operand struct {
+	Value string
+	Type  OperandType
+	// Tuple is the elements of an OpTuple operand, e.g. ('1', '2')
+	Tuple []Operand
+	// Cast is the type of a Postgres cast of the operand, e.g. int for a::int. Value is the operand
+	// without the cast. Empty if none.
+	Cast string
+}
+
+// Canonical returns the normalized value of an OpNumber operand, so numbers equal in value compare
+// equal, e.g. +1.0, 1.00 and 01 are all 1, and 150, 1.5e2 and 15e1 are all 150. The exponent is folded
+// into the decimal point, then the sign is dropped from zero and leading and trailing zeros are trimmed.
+// Numbers which would need more than canonicalMaxZeros padding zeros are written as d.ddde<n> instead,
+// e.g. 1e100. The digits aren't converted to a float, so no precision is lost. Value is returned as is
+// for other operand types and for text which isn't a decimal number.
+func (o Operand) Canonical() string {
+	if o.Type != OpNumber {
+		return o.Value
+	}
+	s := o.Value
+	exp := int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		s = s[:i]
+		if exp, err = strconv.ParseInt(canonicalDigits(o.Value[i+1:]), 10, 32); err != nil {
+			return o.Value
+		}
+	}
+	neg := strings.HasPrefix(s, "-")
+	if neg || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if (intPart == "" && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return o.Value
+	}
+	// the value is 0.<digits> * 10^point
+	digits, point := intPart+fracPart, int64(len(intPart))+exp
+	for len(digits) > 0 && digits[0] == '0' {
+		digits = digits[1:]
+		point--
+	}
+	digits = strings.TrimRight(digits, "0")
+	if digits == "" {
+		return "0"
+	}
+	var sb strings.Builder
+	if neg {
+		sb.WriteByte('-')
+	}
+	n := int64(len(digits))
+	switch {
+	case point < -canonicalMaxZeros || point-n > canonicalMaxZeros:
+		sb.WriteByte(digits[0])
+		if n > 1 {
+			sb.WriteByte('.')
+			sb.WriteString(digits[1:])
+		}
+		sb.WriteByte('e')
+		sb.WriteString(strconv.FormatInt(point-1, 10))
+	case point <= 0:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", int(-point)))
+		sb.WriteString(digits)
+	case point >= n:
+		sb.WriteString(digits)
+		sb.WriteString(strings.Repeat("0", int(point-n)))
+	default:
+		sb.WriteString(digits[:point])
+		sb.WriteByte('.')
+		sb.WriteString(digits[point:])
+	}
+	return sb.String()
+}
+
+// canonicalMaxZeros is the most zeros Canonical pads a number with, before it switches to an exponent
+const canonicalMaxZeros = 32
+
+// canonicalDigits normalizes a signed integer, e.g. an exponent, without leading zeros and a plus
+// sign. It returns an empty string if s isn't an integer.
+func canonicalDigits(s string) string {
+	neg := strings.HasPrefix(s, "-")
+	if neg || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	if s == "" || !isDigits(s) {
+		return ""
+	}
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SortedOperands returns a sorted copy of ops, e.g. to canonicalize IN ('b', 'a') as IN ('a', 'b').
+// Operands are ordered by type, numbers numerically, tuples element by element and other operands
+// lexicographically. If unique is set, duplicates are removed, numbers equal in value, like 1 and 1.0,
+// are duplicates.
+func SortedOperands(ops []Operand, unique bool) []Operand {
+	sorted := append([]Operand(nil), ops...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareOperands(sorted[i], sorted[j]) < 0
+	})
+	if !unique {
+		return sorted
+	}
+	var deduped []Operand
+	for i, op := range sorted {
+		if i == 0 || compareOperands(sorted[i-1], op) != 0 {
+			deduped = append(deduped, op)
+		}
+	}
+	return deduped
+}
+
+// compareOperands returns -1, 0 or 1 if a is less than, equal to or greater than b, see SortedOperands
+func compareOperands(a, b Operand) int {
+	if a.Type != b.Type {
+		if a.Type < b.Type {
+			return -1
+		}
+		return 1
+	}
+	switch a.Type {
+	case OpNumber:
+		x, okX := new(big.Float).SetPrec(256).SetString(a.Value)
+		y, okY := new(big.Float).SetPrec(256).SetString(b.Value)
+		if okX && okY {
+			return x.Cmp(y)
+		}
+	case OpTuple:
+		for i := 0; i < len(a.Tuple) && i < len(b.Tuple); i++ {
+			if c := compareOperands(a.Tuple[i], b.Tuple[i]); c != 0 {
+				return c
+			}
+		}
+		if len(a.Tuple) != len(b.Tuple) {
+			if len(a.Tuple) < len(b.Tuple) {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a.Value, b.Value)
+}
+
 // Condition is a single boolean condition in a WHERE clause
 type Condition struct {
 	// Operand1 is the left hand side operand
 	Operand1 string
 	// Operand1IsField determines if Operand1 is a literal or a field name
 	Operand1Type OperandType
+	// Operand1List is the left hand side operand if Operand1Type is OpTuple, e.g. (a, b) IN (('1', '2')),
+	// or the searched columns if Operand1Type is OpList, for the Match operator
+	Operand1List []Operand
+	// Operand1Cast is the type of a Postgres cast of Operand1, e.g. date for '2021-01-01'::date. Operand1
+	// is the operand without the cast. Empty if none.
+	Operand1Cast string
 	// Operator is e.g. "=", ">"
 	Operator Operator
-	// Operand1 is the right hand side operand
+	// Operand1 is the right hand side operand. Quoted operands are stored as written between the quotes,
+	// escapes included
 	Operand2 string
 	// Operand2IsField determines if Operand2 is a literal or a field name
 	Operand2Type OperandType
+	// Operand2List is the right hand side operand if Operand2Type is OpList, e.g. for IN. If Operand1Type
+	// is OpTuple, the elements are OpTuple operands of the same length
+	Operand2List []Operand
+	// Operand2Cast is the type of a Postgres cast of Operand2, see Operand1Cast
+	Operand2Cast string
+	// Quantifier is set for a comparison with ANY or ALL of the Operand2List values
+	Quantifier Quantifier
+	// Escape is the escape character of a LIKE pattern, set with ESCAPE 'c'. Empty if none.
+	Escape string
+	// Collation is the collation of the comparison, set with COLLATE after either operand, e.g. utf8_bin
+	// or "C" with its quotes. Empty if none.
+	Collation string
+	// Not is set for a negated condition, e.g. NOT a = '1'
+	Not bool
+	// MatchMode is the search modifier of a Match condition, e.g. IN BOOLEAN MODE. Empty for the default
+	// natural language mode.
+	MatchMode string
+}
+
+// swappedOperators are the operators with a different meaning if their operands are swapped, mapped to
+// the operator with the same meaning for the swapped operands
+var swappedOperators = map[Operator]Operator{
+	Gt:  Lt,
+	Lt:  Gt,
+	Gte: Lte,
+	Lte: Gte,
+}
+
+// Normalize returns the condition with a field on the left side, e.g. a = '1' for '1' = a. If Operand1 is
+// a literal and Operand2 is a field, the operands are swapped and an ordering operator is inverted,
+// e.g. '1' < a becomes a > '1'. Other conditions, and those with an operator like IN or LIKE that can't be
+// swapped, are returned unchanged.
+func (c Condition) Normalize() Condition {
+	if !isLiteral(c.Operand1Type) || c.Operand2Type != OpField {
+		return c
+	}
+	switch c.Operator {
+	case Eq, Ne, IsDistinctFrom, IsNotDistinctFrom:
+	case Gt, Lt, Gte, Lte:
+		c.Operator = swappedOperators[c.Operator]
+	default:
+		return c
+	}
+	c.Operand1, c.Operand2 = c.Operand2, c.Operand1
+	c.Operand1Type, c.Operand2Type = c.Operand2Type, c.Operand1Type
+	c.Operand1Cast, c.Operand2Cast = c.Operand2Cast, c.Operand1Cast
+	return c
+}
+
+// Fields returns the names of the fields referenced by the condition, qualified ones as written, e.g. t.a.
+// They're in source order, fields in tuples and lists included. Literals, functions and other operands
+// are skipped.
+func (c Condition) Fields() []string {
+	var fields []string
+	fields = appendFields(fields, c.Operand1List)
+	fields = appendFields(fields, []Operand{{Value: c.Operand1, Type: c.Operand1Type}})
+	fields = appendFields(fields, []Operand{{Value: c.Operand2, Type: c.Operand2Type}})
+	return appendFields(fields, c.Operand2List)
+}
+
+// IsConstant checks if the condition compares literals only, so its value is known without any row,
+// e.g. true for '1' = '1' and false for '1' != '1' or 2 IN (1, 3). ok is false if a field or another
+// non-literal operand is involved, and if the value depends on the database: operands of different
+// types, e.g. '1' = 1, a cast, a collation, a quantifier or an operator other than a comparison, IN or
+// NOT IN.
+func (c Condition) IsConstant() (value bool, ok bool) {
+	if c.Quantifier != NoQuantifier || c.Collation != "" || c.Operand1Cast != "" || c.Operand2Cast != "" {
+		return false, false
+	}
+	left, ok := constantOperand(c.Operand1, c.Operand1Type)
+	if !ok {
+		return false, false
+	}
+	switch c.Operator {
+	case Eq, Ne, Gt, Lt, Gte, Lte:
+		right, ok := constantOperand(c.Operand2, c.Operand2Type)
+		if !ok || right.Type != left.Type {
+			return false, false
+		}
+		value = comparisonHolds(c.Operator, compareOperands(left, right))
+	case In, NotIn:
+		if c.Operand2Type != OpList {
+			return false, false
+		}
+		for _, op := range c.Operand2List {
+			right, ok := constantOperand(op.Value, op.Type)
+			if !ok || right.Type != left.Type || op.Cast != "" {
+				return false, false
+			}
+			value = value || compareOperands(left, right) == 0
+		}
+		value = value != (c.Operator == NotIn)
+	default:
+		return false, false
+	}
+	return value != c.Not, true
+}
+
+// constantOperand returns a quoted string or a valid number, ok is false for other operands
+func constantOperand(value string, opType OperandType) (Operand, bool) {
+	switch opType {
+	case OpQuoted:
+		return Operand{Value: value, Type: OpQuoted}, true
+	case OpNumber:
+		if _, ok := new(big.Float).SetString(value); ok {
+			return Operand{Value: value, Type: OpNumber}, true
+		}
+	}
+	return Operand{}, false
+}
+
+// LeftField returns Operand1 and true if it's an OpField operand, e.g. a in a = '1'
+func (c Condition) LeftField() (string, bool) {
+	return c.Operand1, c.Operand1Type == OpField
+}
+
+// LeftString returns Operand1 and true if it's an OpQuoted operand, as stored, i.e. without the quotes
+func (c Condition) LeftString() (string, bool) {
+	return c.Operand1, c.Operand1Type == OpQuoted
+}
+
+// LeftNumber returns Operand1 and true if it's an OpNumber operand, as written, e.g. 1.5
+func (c Condition) LeftNumber() (string, bool) {
+	return c.Operand1, c.Operand1Type == OpNumber
+}
+
+// RightField returns Operand2 and true if it's an OpField operand, e.g. b in a = b
+func (c Condition) RightField() (string, bool) {
+	return c.Operand2, c.Operand2Type == OpField
+}
+
+// RightString returns Operand2 and true if it's an OpQuoted operand, as stored, i.e. without the quotes
+func (c Condition) RightString() (string, bool) {
+	return c.Operand2, c.Operand2Type == OpQuoted
+}
+
+// RightNumber returns Operand2 and true if it's an OpNumber operand, as written, e.g. 1.5
+func (c Condition) RightNumber() (string, bool) {
+	return c.Operand2, c.Operand2Type == OpNumber
+}
+
+func appendFields(fields []string, ops []Operand) []string {
+	for _, op := range ops {
+		if op.Type == OpField {
+			fields = append(fields, op.Value)
+		}
+		fields = appendFields(fields, op.Tuple)
+	}
+	return fields
+}
+
+// EqualityFilters returns the fields compared for equality with a literal, mapped to the literal, e.g.
+// {a: '1'} for WHERE a = '1' AND b > 2. The literal may be on either side. Negated conditions and
+// conditions with a collation are excluded. If a field has several such conditions, the first is kept.
+func (q Query) EqualityFilters() map[string]Operand {
+	filters := map[string]Operand{}
+	for _, c := range q.Conditions {
+		c = c.Normalize()
+		if c.Operator != Eq || c.Not || c.Collation != "" || c.Operand1Type != OpField || !isLiteral(c.Operand2Type) {
+			continue
+		}
+		if _, ok := filters[c.Operand1]; !ok {
+			filters[c.Operand1] = Operand{Value: c.Operand2, Type: c.Operand2Type}
+		}
+	}
+	return filters
+}
+
+// isLiteral checks if an operand of opType is a constant value, e.g. '1' or 1
+func isLiteral(opType OperandType) bool {
+	switch opType {
+	case OpQuoted, OpNumber, OpInterval, OpHex, OpBit:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewSelect returns a SELECT query of fields from table. Without fields, all fields are selected with *.
+func NewSelect(table string, fields ...string) *Query {
+	if len(fields) == 0 {
+		fields = []string{"*"}
+	}
+	return &Query{
+		Type:      Select,
+		TableName: table,
+		Fields:    fields,
+		Aliases:   make([]string, len(fields)),
+	}
+}
+
+// WithLimit sets the LIMIT of the query and returns q for chaining
+func (q *Query) WithLimit(n int64) *Query {
+	q.Limit = &n
+	q.LimitParam = nil
+	q.LimitPercent = false
+	return q
+}
+
+// WithOffset sets the OFFSET of the query and returns q for chaining
+func (q *Query) WithOffset(n int64) *Query {
+	q.Offset = &n
+	q.OffsetParam = nil
+	return q
+}
+
+// AddCondition appends c to the conditions, joined by AND, and returns q for chaining
+func (q *Query) AddCondition(c Condition) *Query {
+	q.Conditions = append(q.Conditions, c)
+	return q
+}
+
+// FilterConditions returns a copy of the query with only the conditions for which keep returns true, in
+// their order. The conditions are joined by AND, so removing one widens the query. Dropping all
+// conditions of an UPDATE or DELETE makes it affect all rows, see Warnings.
+func (q Query) FilterConditions(keep func(Condition) bool) Query {
+	c := q.Clone()
+	kept := c.Conditions[:0]
+	for _, cond := range c.Conditions {
+		if keep(cond) {
+			kept = append(kept, cond)
+		}
+	}
+	c.Conditions = nil
+	if len(kept) > 0 {
+		c.Conditions = kept
+	}
+	return c
+}
+
+// AddEq appends the condition field = 'value' and returns q for chaining
+func (q *Query) AddEq(field, value string) *Query {
+	return q.AddCondition(Condition{Operand1: field, Operand1Type: OpField, Operator: Eq, Operand2: value, Operand2Type: OpQuoted})
+}
+
+// UpdateType returns the type of the Updates value of field, OpQuoted unless set in UpdateTypes
+func (q Query) UpdateType(field string) OperandType {
+	if opType, ok := q.UpdateTypes[field]; ok {
+		return opType
+	}
+	return OpQuoted
+}
+
+// Category returns the coarse category of the query type: "read" for Select and Describe, "write" for Insert, Update
+// and Delete, "ddl" for schema changes. Transaction control, e.g. Begin, is "write", as it must be routed
+// like the writes it wraps. It returns an empty string for UnknownType.
+func (q Query) Category() string {
+	switch q.Type {
+	case Select, Describe:
+		return "read"
+	case Insert, Update, Delete, Begin, Commit, Rollback, Savepoint:
+		return "write"
+	case Alter:
+		return "ddl"
+	default:
+		return ""
+	}
+}
+
+// Validate checks that the query has a known Type. It returns ErrEmptyType for UnknownType and an error
+// naming the value for a Type outside of the defined ones, e.g. Type(99).
+func (q Query) Validate() error {
+	if q.Type == UnknownType {
+		return ErrEmptyType
+	}
+	if q.Type < 0 || int(q.Type) >= len(TypeString) {
+		return fmt.Errorf("unknown query type %d", int(q.Type))
+	}
+	return nil
+}
+
+// IsWildcardSelect checks if the query is SELECT *, i.e. Fields is exactly ["*"]
+func (q Query) IsWildcardSelect() bool {
+	return q.Type == Select && len(q.Fields) == 1 && q.Fields[0] == "*"
+}
+
+// HasWildcard checks if any field is the * wildcard or a qualified wildcard, e.g. t.*
+func (q Query) HasWildcard() bool {
+	for _, field := range q.Fields {
+		if field == "*" || strings.HasSuffix(field, ".*") {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnings returns the non-fatal issues of the query, in a fixed order: an UPDATE or DELETE without
+// conditions, a * wildcard in the SELECTed fields and conditions comparing literals only, which are
+// always true or always false, e.g. '1' = '1'. It's nil if there are no issues.
+func (q Query) Warnings() []string {
+	var warnings []string
+	if (q.Type == Update || q.Type == Delete) && len(q.Conditions) == 0 {
+		warnings = append(warnings, strings.ToUpper(enumString(TypeString, int(q.Type)))+" without WHERE affects all rows")
+	}
+	if q.Type == Select && q.HasWildcard() {
+		warnings = append(warnings, "SELECT * depends on the table columns")
+	}
+	for _, c := range q.Conditions {
+		if literalOnly(c) {
+			warnings = append(warnings, "condition "+c.String()+" compares literals only")
+		}
+	}
+	return warnings
+}
+
+// literalOnly checks if both operands of c are literals, lists included
+func literalOnly(c Condition) bool {
+	if c.Operator == Match {
+		return false
+	}
+	op1 := Operand{Value: c.Operand1, Type: c.Operand1Type, Tuple: c.Operand1List}
+	op2 := Operand{Value: c.Operand2, Type: c.Operand2Type, Tuple: c.Operand2List}
+	return literalOperand(op1) && literalOperand(op2)
+}
+
+// literalOperand checks if op is a literal, or a list or tuple of literals
+func literalOperand(op Operand) bool {
+	if op.Type != OpList && op.Type != OpTuple {
+		return isLiteral(op.Type)
+	}
+	for _, e := range op.Tuple {
+		if !literalOperand(e) {
+			return false
+		}
+	}
+	return len(op.Tuple) > 0
+}
+
+// AlterActionType is the kind of an ALTER TABLE action, e.g. ADD COLUMN
+type AlterActionType int
+
+const (
+	// UnknownAlterAction is the zero value for an AlterActionType
+	UnknownAlterAction AlterActionType = iota
+	// AddColumn -> "ADD COLUMN"
+	AddColumn
+	// DropColumn -> "DROP COLUMN"
+	DropColumn
+)
+
+// AlterActionString is a string slice with the names of all alter actions in order
+var AlterActionString = []string{
+	"UnknownAlterAction",
+	"AddColumn",
+	"DropColumn",
+}
+
+// String returns the name of the alter action, e.g. AddColumn. It returns the number for an undefined value.
+func (a AlterActionType) String() string {
+	return enumString(AlterActionString, int(a))
+}
+
+// AlterAction is a single action of an ALTER TABLE query
+type AlterAction struct {
+	// Action is e.g. AddColumn
+	Action AlterActionType
+	// Column is the added or dropped column name
+	Column string
+	// ColumnType is the type of an added column, e.g. INT or VARCHAR(255)
+	ColumnType string
+}
+
+// Depth returns the nesting depth of the query, a rough measure of its complexity.
+// Conditions are a flat list joined by AND and the only subquery is a derived table, so a
+// parsed query has depth 1, plus the depth of its derived table. The zero Query has depth 0.
+func (q Query) Depth() int {
+	if q.Type == UnknownType {
+		return 0
+	}
+	if q.FromQuery != nil {
+		return 1 + q.FromQuery.Depth()
+	}
+	return 1
+}
+
+// EqualIgnoringAliases checks if q and other are equal in all fields except Aliases, e.g. for
+// SELECT a AS x FROM b and SELECT a FROM b. The aliases of a derived table in FromQuery are compared.
+func (q Query) EqualIgnoringAliases(other Query) bool {
+	q.Aliases, other.Aliases = nil, nil
+	return reflect.DeepEqual(q, other)
+}
+
+// Clone returns a deep copy of the query, so the copy can be modified without changing q
+func (q Query) Clone() Query {
+	c := q
+	if q.Conditions != nil {
+		c.Conditions = make([]Condition, len(q.Conditions))
+		for i, cond := range q.Conditions {
+			cond.Operand1List = cloneOperands(cond.Operand1List)
+			cond.Operand2List = cloneOperands(cond.Operand2List)
+			c.Conditions[i] = cond
+		}
+	}
+	if q.Updates != nil {
+		c.Updates = make(map[string]string, len(q.Updates))
+		for k, v := range q.Updates {
+			c.Updates[k] = v
+		}
+	}
+	if q.UpdateTypes != nil {
+		c.UpdateTypes = make(map[string]OperandType, len(q.UpdateTypes))
+		for k, v := range q.UpdateTypes {
+			c.UpdateTypes[k] = v
+		}
+	}
+	if q.UpdateCasts != nil {
+		c.UpdateCasts = make(map[string]string, len(q.UpdateCasts))
+		for k, v := range q.UpdateCasts {
+			c.UpdateCasts[k] = v
+		}
+	}
+	if q.FromQuery != nil {
+		from := q.FromQuery.Clone()
+		c.FromQuery = &from
+	}
+	if q.UpdateFrom != nil {
+		c.UpdateFrom = append([]string(nil), q.UpdateFrom...)
+	}
+	if q.DeleteUsing != nil {
+		c.DeleteUsing = append([]string(nil), q.DeleteUsing...)
+	}
+	if q.Inserts != nil {
+		c.Inserts = make([][]string, len(q.Inserts))
+		for i, row := range q.Inserts {
+			c.Inserts[i] = append([]string(nil), row...)
+		}
+	}
+	if q.Fields != nil {
+		c.Fields = append([]string(nil), q.Fields...)
+	}
+	if q.Aliases != nil {
+		c.Aliases = append([]string(nil), q.Aliases...)
+	}
+	if q.Windows != nil {
+		c.Windows = append([]Window(nil), q.Windows...)
+	}
+	if q.AlterActions != nil {
+		c.AlterActions = append([]AlterAction(nil), q.AlterActions...)
+	}
+	if q.Limit != nil {
+		limit := *q.Limit
+		c.Limit = &limit
+	}
+	if q.Offset != nil {
+		offset := *q.Offset
+		c.Offset = &offset
+	}
+	if q.LimitParam != nil {
+		param := *q.LimitParam
+		c.LimitParam = &param
+	}
+	if q.OffsetParam != nil {
+		param := *q.OffsetParam
+		c.OffsetParam = &param
+	}
+	if q.Comments != nil {
+		c.Comments = append([]Comment(nil), q.Comments...)
+	}
+	return c
+}
+
+// RewriteTables returns a copy of the query with the table name passed through fn, e.g. for sharding.
+// For a qualified name like schema.table only the table part is passed to fn, use
+// RewriteTablesWithSchema to rewrite the whole name.
+func (q Query) RewriteTables(fn func(name string) string) Query {
+	return q.RewriteTablesWithSchema(func(name string) string {
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			return name[:i+1] + fn(name[i+1:])
+		}
+		return fn(name)
+	})
+}
+
+// RenameColumn returns a copy of the query with the references to the column old renamed to new, in
+// fields, conditions, updates, alter actions and the derived table. For a qualified reference like t.old
+// only the column part is renamed.
+func (q Query) RenameColumn(old, new string) Query {
+	rename := func(name string) string {
+		if name == old {
+			return new
+		}
+		if strings.HasSuffix(name, "."+old) {
+			return name[:len(name)-len(old)] + new
+		}
+		return name
+	}
+	c := q.Clone()
+	for i, field := range c.Fields {
+		c.Fields[i] = rename(field)
+	}
+	for i := range c.Conditions {
+		cond := &c.Conditions[i]
+		if cond.Operand1Type == OpField {
+			cond.Operand1 = rename(cond.Operand1)
+		}
+		if cond.Operand2Type == OpField {
+			cond.Operand2 = rename(cond.Operand2)
+		}
+		renameOperands(cond.Operand1List, rename)
+		renameOperands(cond.Operand2List, rename)
+	}
+	if c.Updates != nil {
+		updates := make(map[string]string, len(c.Updates))
+		var updateTypes map[string]OperandType
+		if c.UpdateTypes != nil {
+			updateTypes = make(map[string]OperandType, len(c.UpdateTypes))
+		}
+		for field, value := range c.Updates {
+			opType := c.UpdateType(field)
+			if opType == OpField {
+				value = rename(value)
+			}
+			field = rename(field)
+			updates[field] = value
+			if opType != OpQuoted {
+				updateTypes[field] = opType
+			}
+		}
+		c.Updates, c.UpdateTypes = updates, updateTypes
+	}
+	if c.UpdateCasts != nil {
+		updateCasts := make(map[string]string, len(c.UpdateCasts))
+		for field, cast := range c.UpdateCasts {
+			updateCasts[rename(field)] = cast
+		}
+		c.UpdateCasts = updateCasts
+	}
+	for i := range c.AlterActions {
+		c.AlterActions[i].Column = rename(c.AlterActions[i].Column)
+	}
+	if c.FromQuery != nil {
+		from := c.FromQuery.RenameColumn(old, new)
+		c.FromQuery = &from
+	}
+	return c
+}
+
+func renameOperands(ops []Operand, rename func(string) string) {
+	for i := range ops {
+		if ops[i].Type == OpField {
+			ops[i].Value = rename(ops[i].Value)
+		}
+		renameOperands(ops[i].Tuple, rename)
+	}
+}
+
+// RewriteTablesWithSchema returns a copy of the query with the full table name, schema included, passed
+// through fn.
+func (q Query) RewriteTablesWithSchema(fn func(name string) string) Query {
+	c := q.Clone()
+	if c.TableName != "" {
+		c.TableName = fn(c.TableName)
+	}
+	for i, table := range c.UpdateFrom {
+		c.UpdateFrom[i] = fn(table)
+	}
+	for i, table := range c.DeleteUsing {
+		c.DeleteUsing[i] = fn(table)
+	}
+	if c.FromQuery != nil {
+		from := c.FromQuery.RewriteTablesWithSchema(fn)
+		c.FromQuery = &from
+	}
+	return c
+}
+
+func cloneOperands(ops []Operand) []Operand {
+	if ops == nil {
+		return nil
+	}
+	c := make([]Operand, len(ops))
+	for i, op := range ops {
+		op.Tuple = cloneOperands(op.Tuple)
+		c[i] = op
+	}
+	return c
+}
+
+// Literals returns the quoted, number, NULL and boolean values of the query, from updates, inserts, the derived table
+// and conditions, e.g. to find hardcoded secrets. Fields and functions are excluded. Updates are unordered, so their
+// values are returned sorted by field name, otherwise values are in source order.
+func (q Query) Literals() []Operand {
+	var literals []Operand
+	fields := make([]string, 0, len(q.Updates))
+	for field := range q.Updates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		literals = appendLiterals(literals, []Operand{{Value: q.Updates[field], Type: q.UpdateType(field)}})
+	}
+	for _, row := range q.Inserts {
+		for _, value := range row {
+			literals = append(literals, Operand{Value: value, Type: OpQuoted})
+		}
+	}
+	if q.FromQuery != nil {
+		literals = append(literals, q.FromQuery.Literals()...)
+	}
+	for _, c := range q.Conditions {
+		literals = appendLiterals(literals, c.Operand1List)
+		literals = appendLiterals(literals, []Operand{{Value: c.Operand1, Type: c.Operand1Type}})
+		literals = appendLiterals(literals, []Operand{{Value: c.Operand2, Type: c.Operand2Type}})
+		literals = appendLiterals(literals, c.Operand2List)
+	}
+	return literals
+}
+
+// PlaceholderCount returns the number of prepared statement parameters in the query, e.g. 3 for
+// SELECT a FROM b WHERE c = ? AND d IN (?, ?). Placeholders in updates, the derived table, conditions,
+// LIMIT and OFFSET are counted.
+func (q Query) PlaceholderCount() int {
+	n := 0
+	for field := range q.Updates {
+		if q.UpdateType(field) == OpPlaceholder {
+			n++
+		}
+	}
+	if q.FromQuery != nil {
+		n += q.FromQuery.PlaceholderCount()
+	}
+	for _, c := range q.Conditions {
+		n += countPlaceholders(c.Operand1List)
+		n += countPlaceholders([]Operand{{Type: c.Operand1Type}, {Type: c.Operand2Type}})
+		n += countPlaceholders(c.Operand2List)
+	}
+	if q.LimitParam != nil {
+		n++
+	}
+	if q.OffsetParam != nil {
+		n++
+	}
+	return n
+}
+
+// OperandCount returns the number of operands in the query: the operands of the conditions, with each element of
+// a list or tuple counted, the Updates values and the Inserts values. The derived table is counted too, e.g. 5 for
+// SELECT a FROM b WHERE c = '1' AND d IN (1, 2). It doesn't allocate.
+func (q Query) OperandCount() int {
+	n := len(q.Updates)
+	for _, row := range q.Inserts {
+		n += len(row)
+	}
+	if q.FromQuery != nil {
+		n += q.FromQuery.OperandCount()
+	}
+	for _, c := range q.Conditions {
+		n += countSide(c.Operand1Type, c.Operand1List)
+		n += countSide(c.Operand2Type, c.Operand2List)
+	}
+	return n
+}
+
+// countSide returns the number of operands on one side of a condition, 0 if the side is missing
+func countSide(opType OperandType, list []Operand) int {
+	if opType == OpList || opType == OpTuple {
+		return countOperands(list)
+	}
+	if opType == OpUnknown {
+		return 0
+	}
+	return 1
+}
+
+func countOperands(ops []Operand) int {
+	n := 0
+	for _, op := range ops {
+		if op.Type == OpTuple {
+			n += countOperands(op.Tuple)
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+func countPlaceholders(ops []Operand) int {
+	n := 0
+	for _, op := range ops {
+		if op.Type == OpPlaceholder {
+			n++
+		}
+		n += countPlaceholders(op.Tuple)
+	}
+	return n
+}
+
+func appendLiterals(literals []Operand, ops []Operand) []Operand {
+	for _, op := range ops {
+		switch op.Type {
+		case OpQuoted, OpNumber, OpInterval, OpHex, OpBit, OpNull, OpBool:
+			literals = append(literals, Operand{Value: op.Value, Type: op.Type})
+		case OpTuple:
+			literals = appendLiterals(literals, op.Tuple)
+		}
+	}
+	return literals
 }
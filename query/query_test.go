@@ -0,0 +1,979 @@
+package query
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepth(t *testing.T) {
+	require.Equal(t, 0, Query{}.Depth())
+	require.Equal(t, 3, Query{
+		Type:      Select,
+		Fields:    []string{"a"},
+		FromQuery: &Query{Type: Select, Fields: []string{"a"}, FromQuery: &Query{Type: Select, TableName: "b", Fields: []string{"a"}}, FromAlias: "c"},
+		FromAlias: "d",
+	}.Depth())
+	require.Equal(t, 1, Query{
+		Type:      Select,
+		TableName: "b",
+		Fields:    []string{"a"},
+		Aliases:   []string{""},
+		Conditions: []Condition{
+			{Operand1: "c", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted},
+		},
+	}.Depth())
+}
+
+func TestStringEscaping(t *testing.T) {
+	ts := []struct {
+		Value    string
+		Expected string
+	}{
+		{Value: "it's", Expected: `a = 'it''s'`},
+		{Value: "it''s", Expected: `a = 'it''''s'`},
+		{Value: `it\'s`, Expected: `a = 'it\\''s'`},
+		{Value: `\' OR 1=1 --`, Expected: `a = '\\'' OR 1=1 --'`},
+		{Value: `'; DELETE FROM t; --`, Expected: `a = '''; DELETE FROM t; --'`},
+		{Value: `back\slash`, Expected: `a = 'back\slash'`},
+		{Value: `back\\slash`, Expected: `a = 'back\\\slash'`},
+		{Value: `trailing\`, Expected: `a = 'trailing\\'`},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Value, func(t *testing.T) {
+			c := Condition{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: tc.Value, Operand2Type: OpQuoted}
+			s := c.String()
+			require.Equal(t, tc.Expected, s)
+			// the literal can't be terminated early, with standard strings or with backslash escapes
+			literal := s[len("a = '") : len(s)-1]
+			require.NotContains(t, strings.ReplaceAll(literal, "''", ""), "'")
+			require.NotContains(t, strings.ReplaceAll(strings.ReplaceAll(literal, `\\`, ""), "''", ""), "'")
+		})
+	}
+
+	q := Query{Type: Insert, TableName: "it's", Fields: []string{"a"}, Inserts: [][]string{{"x'y"}}}
+	require.Equal(t, `INSERT INTO 'it''s' (a) VALUES ('x''y')`, q.String())
+}
+
+func TestKeywords(t *testing.T) {
+	words := Keywords()
+	require.True(t, sort.StringsAreSorted(words))
+	for _, word := range words {
+		require.True(t, IsKeyword(strings.ToLower(word)), word)
+	}
+	require.False(t, IsKeyword("a"))
+	require.False(t, IsKeyword(""))
+}
+
+func TestCategory(t *testing.T) {
+	expected := map[Type]string{
+		UnknownType: "",
+		Select:      "read",
+		Update:      "write",
+		Insert:      "write",
+		Delete:      "write",
+		Alter:       "ddl",
+		Begin:       "write",
+		Commit:      "write",
+		Rollback:    "write",
+		Savepoint:   "write",
+		Describe:    "read",
+	}
+	for i := range TypeString {
+		typ := Type(i)
+		category, ok := expected[typ]
+		require.True(t, ok, "no expected category for %s", TypeString[i])
+		require.Equal(t, category, Query{Type: typ}.Category(), TypeString[i])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for i := 1; i < len(TypeString); i++ {
+		require.NoError(t, Query{Type: Type(i)}.Validate(), TypeString[i])
+	}
+	require.Equal(t, ErrEmptyType, Query{}.Validate())
+	require.EqualError(t, Query{Type: Type(99)}.Validate(), "unknown query type 99")
+	require.EqualError(t, Query{Type: Type(-1)}.Validate(), "unknown query type -1")
+	require.Equal(t, "", Query{Type: Type(99), TableName: "a"}.String())
+}
+
+func TestFormat(t *testing.T) {
+	q := Query{
+		Type:      Select,
+		TableName: "my table",
+		Fields:    []string{"a", "select", "my col", "count(*)", "*"},
+		Aliases:   []string{"", "", "b", "from", ""},
+		Conditions: []Condition{
+			{Operand1: "where", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted},
+			{Operand1: "a_1", Operand1Type: OpField, Operator: Gt, Operand2: "x\"y", Operand2Type: OpField},
+		},
+	}
+	ts := []struct {
+		Quote    IdentifierQuote
+		Expected string
+	}{
+		{
+			Quote:    QuoteNone,
+			Expected: `SELECT a, select, my col AS b, count(*) AS from, * FROM my table WHERE where = '1' AND a_1 > x"y`,
+		},
+		{
+			Quote:    QuoteDouble,
+			Expected: `SELECT a, "select", "my col" AS b, count(*) AS "from", * FROM "my table" WHERE "where" = '1' AND a_1 > "x""y"`,
+		},
+		{
+			Quote:    QuoteBacktick,
+			Expected: "SELECT a, `select`, `my col` AS b, count(*) AS `from`, * FROM `my table` WHERE `where` = '1' AND a_1 > `x\"y`",
+		},
+	}
+	for _, tc := range ts {
+		require.Equal(t, tc.Expected, q.Format(tc.Quote))
+	}
+
+	caseQuery := Query{Type: Select, TableName: "t", Fields: []string{"CASE WHEN a = 1 THEN 'x' END"}, Aliases: []string{"end"}}
+	require.Equal(t, `SELECT CASE WHEN a = 1 THEN 'x' END AS "end" FROM t`, caseQuery.Format(QuoteDouble))
+}
+
+func TestClone(t *testing.T) {
+	q := Query{
+		Type:       Insert,
+		TableName:  "a",
+		Fields:     []string{"b", "c"},
+		Inserts:    [][]string{{"1", "2"}},
+		Updates:    map[string]string{"b": "1"},
+		Conditions: []Condition{{Operand1: "b", Operand1Type: OpField, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "1", Type: OpQuoted}}}},
+	}
+	c := q.Clone()
+	require.Equal(t, q, c)
+	c.Fields[0] = "x"
+	c.Inserts[0][0] = "x"
+	c.Updates["b"] = "x"
+	c.Conditions[0].Operand2List[0].Value = "x"
+	require.Equal(t, "b", q.Fields[0])
+	require.Equal(t, "1", q.Inserts[0][0])
+	require.Equal(t, "1", q.Updates["b"])
+	require.Equal(t, "1", q.Conditions[0].Operand2List[0].Value)
+}
+
+func TestEqualIgnoringAliases(t *testing.T) {
+	q := Query{
+		Type:      Select,
+		TableName: "a",
+		Fields:    []string{"b", "c"},
+		Aliases:   []string{"x", ""},
+		Conditions: []Condition{
+			{Operand1: "b", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted},
+		},
+	}
+	other := q.Clone()
+	other.Aliases = []string{"", "y"}
+	require.True(t, q.EqualIgnoringAliases(other))
+	require.True(t, q.EqualIgnoringAliases(q))
+	require.Equal(t, []string{"x", ""}, q.Aliases)
+
+	other.Fields[1] = "d"
+	require.False(t, q.EqualIgnoringAliases(other))
+	other = q.Clone()
+	other.Conditions[0].Operand2 = "2"
+	require.False(t, q.EqualIgnoringAliases(other))
+	other = q.Clone()
+	other.FromQuery = &Query{Type: Select, TableName: "t", Fields: []string{"a"}, Aliases: []string{"b"}}
+	require.False(t, q.EqualIgnoringAliases(other))
+}
+
+func TestRewriteTables(t *testing.T) {
+	shard := func(name string) string { return name + "_1" }
+	ts := []struct {
+		Name       string
+		TableName  string
+		Expected   string
+		WithSchema bool
+	}{
+		{Name: "plain", TableName: "a", Expected: "a_1"},
+		{Name: "qualified", TableName: "s.a", Expected: "s.a_1"},
+		{Name: "qualified with schema", TableName: "s.a", Expected: "s.a_1", WithSchema: true},
+		{Name: "schema rewrite", TableName: "s", Expected: "s_1", WithSchema: true},
+		{Name: "no table", TableName: "", Expected: ""},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			q := Query{Type: Select, TableName: tc.TableName, Fields: []string{"b"}}
+			var got Query
+			if tc.WithSchema {
+				got = q.RewriteTablesWithSchema(shard)
+			} else {
+				got = q.RewriteTables(shard)
+			}
+			require.Equal(t, tc.Expected, got.TableName)
+			require.Equal(t, tc.TableName, q.TableName)
+		})
+	}
+}
+
+func TestRewriteTablesUpdateFromDeleteUsing(t *testing.T) {
+	q := Query{Type: Update, TableName: "a", Updates: map[string]string{"x": "b.y"}, UpdateFrom: []string{"b", "s.c"}}
+	got := q.RewriteTables(func(name string) string { return name + "_1" })
+	require.Equal(t, "a_1", got.TableName)
+	require.Equal(t, []string{"b_1", "s.c_1"}, got.UpdateFrom)
+	require.Equal(t, []string{"b", "s.c"}, q.UpdateFrom)
+
+	q = Query{Type: Delete, TableName: "a", DeleteUsing: []string{"b"}}
+	got = q.RewriteTables(func(name string) string { return name + "_1" })
+	require.Equal(t, []string{"b_1"}, got.DeleteUsing)
+	require.Equal(t, []string{"b"}, q.DeleteUsing)
+}
+
+func TestLiterals(t *testing.T) {
+	ts := []struct {
+		Name     string
+		Query    Query
+		Expected []Operand
+	}{
+		{
+			Name: "update",
+			Query: Query{
+				Type:        Update,
+				TableName:   "a",
+				Updates:     map[string]string{"c": "2", "b": "1", "e": "b"},
+				UpdateTypes: map[string]OperandType{"e": OpField},
+				Conditions: []Condition{
+					{Operand1: "d", Operand1Type: OpField, Operator: Eq, Operand2: "3", Operand2Type: OpNumber},
+				},
+			},
+			Expected: []Operand{{Value: "1", Type: OpQuoted}, {Value: "2", Type: OpQuoted}, {Value: "3", Type: OpNumber}},
+		},
+		{
+			Name:     "insert",
+			Query:    Query{Type: Insert, TableName: "a", Fields: []string{"b", "c"}, Inserts: [][]string{{"1", "2"}, {"3", "4"}}},
+			Expected: []Operand{{Value: "1", Type: OpQuoted}, {Value: "2", Type: OpQuoted}, {Value: "3", Type: OpQuoted}, {Value: "4", Type: OpQuoted}},
+		},
+		{
+			Name: "conditions",
+			Query: Query{
+				Type:      Select,
+				TableName: "a",
+				Fields:    []string{"b"},
+				Conditions: []Condition{
+					{Operand1: "secret", Operand1Type: OpQuoted, Operator: Eq, Operand2: "b", Operand2Type: OpField},
+					{Operand1: "lower(c)", Operand1Type: OpFunc, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "d", Type: OpField}, {Value: "5", Type: OpNumber}}},
+					{
+						Operand1Type: OpTuple, Operand1List: []Operand{{Value: "b", Type: OpField}, {Value: "6", Type: OpQuoted}},
+						Operator: In, Operand2Type: OpList,
+						Operand2List: []Operand{{Type: OpTuple, Tuple: []Operand{{Value: "7", Type: OpQuoted}, {Value: "c", Type: OpField}}}},
+					},
+				},
+			},
+			Expected: []Operand{{Value: "secret", Type: OpQuoted}, {Value: "5", Type: OpNumber}, {Value: "6", Type: OpQuoted}, {Value: "7", Type: OpQuoted}},
+		},
+		{
+			Name:  "no literals",
+			Query: Query{Type: Select, TableName: "a", Fields: []string{"b"}},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Expected, tc.Query.Literals())
+		})
+	}
+}
+
+func TestOperandDump(t *testing.T) {
+	ts := []struct {
+		Operand  Operand
+		Quote    IdentifierQuote
+		Expected string
+	}{
+		{Operand: Operand{Value: "a_1", Type: OpField}, Quote: QuoteDouble, Expected: `a_1`},
+		{Operand: Operand{Value: "my col", Type: OpField}, Quote: QuoteDouble, Expected: `"my col"`},
+		{Operand: Operand{Value: "select", Type: OpField}, Quote: QuoteBacktick, Expected: "`select`"},
+		{Operand: Operand{Value: "select", Type: OpField}, Quote: QuoteNone, Expected: `select`},
+		{Operand: Operand{Value: "it's", Type: OpQuoted}, Quote: QuoteDouble, Expected: `'it''s'`},
+		{Operand: Operand{Value: "1", Type: OpNumber}, Quote: QuoteDouble, Expected: `1`},
+		{Operand: Operand{Value: "lower(a)", Type: OpFunc}, Quote: QuoteDouble, Expected: `lower(a)`},
+		{
+			Operand:  Operand{Type: OpTuple, Tuple: []Operand{{Value: "from", Type: OpField}, {Value: "1", Type: OpQuoted}}},
+			Quote:    QuoteDouble,
+			Expected: `("from", '1')`,
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Expected, func(t *testing.T) {
+			require.Equal(t, tc.Expected, tc.Operand.Dump(tc.Quote))
+		})
+	}
+}
+
+func TestOperandCanonical(t *testing.T) {
+	ts := []struct {
+		Value    string
+		Type     OperandType
+		Expected string
+	}{
+		{Value: "1", Type: OpNumber, Expected: "1"},
+		{Value: "+1.0", Type: OpNumber, Expected: "1"},
+		{Value: "1.00", Type: OpNumber, Expected: "1"},
+		{Value: "007.50", Type: OpNumber, Expected: "7.5"},
+		{Value: ".5", Type: OpNumber, Expected: "0.5"},
+		{Value: "-0.0", Type: OpNumber, Expected: "0"},
+		{Value: "-12.340", Type: OpNumber, Expected: "-12.34"},
+		{Value: "1.5E+03", Type: OpNumber, Expected: "1500"},
+		{Value: "2e0", Type: OpNumber, Expected: "2"},
+		{Value: "150", Type: OpNumber, Expected: "150"},
+		{Value: "1.5e2", Type: OpNumber, Expected: "150"},
+		{Value: "15e1", Type: OpNumber, Expected: "150"},
+		{Value: "-0.0015e3", Type: OpNumber, Expected: "-1.5"},
+		{Value: "12.5e-3", Type: OpNumber, Expected: "0.0125"},
+		{Value: "0e5", Type: OpNumber, Expected: "0"},
+		{Value: "1.50e100", Type: OpNumber, Expected: "1.5e100"},
+		{Value: "-1e-100", Type: OpNumber, Expected: "-1e-100"},
+		{Value: "1e99999999999", Type: OpNumber, Expected: "1e99999999999"},
+		{Value: "12345678901234567890.10", Type: OpNumber, Expected: "12345678901234567890.1"},
+		{Value: "1.2.3", Type: OpNumber, Expected: "1.2.3"},
+		{Value: "+-1", Type: OpNumber, Expected: "+-1"},
+		{Value: "1.0", Type: OpQuoted, Expected: "1.0"},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Value, func(t *testing.T) {
+			require.Equal(t, tc.Expected, Operand{Value: tc.Value, Type: tc.Type}.Canonical())
+		})
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	ts := []struct {
+		Pattern  string
+		Escape   string
+		Expected string
+		Matches  []string
+		Err      string
+	}{
+		{Pattern: "a%b_", Expected: "(?s)^a.*b.$", Matches: []string{"ab1", "axyzb2", "a\nb3"}},
+		{Pattern: "1.5*", Expected: `(?s)^1\.5\*$`, Matches: []string{"1.5*"}},
+		{Pattern: "x!%y!_!!", Escape: "!", Expected: "(?s)^x%y_!$", Matches: []string{"x%y_!"}},
+		{Pattern: "10\\%", Escape: "\\", Expected: "(?s)^10%$", Matches: []string{"10%"}},
+		{Pattern: "it's'%", Expected: "(?s)^it's'.*$", Matches: []string{"it's'", "it's's"}},
+		{Pattern: "é_", Expected: "(?s)^é.$", Matches: []string{"éè"}},
+		{Pattern: "x!", Escape: "!", Err: "LIKE pattern ends with the escape character '!'"},
+		{Pattern: "x!y", Escape: "!", Err: "LIKE pattern has an invalid escape sequence at 1"},
+		{Pattern: "x", Escape: "!!", Err: "LIKE escape must be a single character, not '!!'"},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Pattern, func(t *testing.T) {
+			re, err := LikePattern(Operand{Value: tc.Pattern, Type: OpQuoted}, tc.Escape)
+			if tc.Err != "" {
+				require.EqualError(t, err, tc.Err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, re)
+			for _, value := range tc.Matches {
+				require.Regexp(t, regexp.MustCompile(re), value)
+			}
+		})
+	}
+
+	_, err := LikePattern(Operand{Value: "a", Type: OpField}, "")
+	require.EqualError(t, err, "LIKE pattern must be a quoted string, not OpField")
+	re, err := LikePattern(Operand{Value: "a_", Type: OpQuoted}, "")
+	require.NoError(t, err)
+	require.NotRegexp(t, regexp.MustCompile(re), "xa1")
+	require.NotRegexp(t, regexp.MustCompile(re), "a12")
+}
+
+func TestCompileConditions(t *testing.T) {
+	ts := []struct {
+		Name       string
+		Conditions []Condition
+		Row        map[string]interface{}
+		Expected   bool
+		Err        string
+	}{
+		{
+			Name:       "string equal",
+			Conditions: []Condition{{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "it's", Operand2Type: OpQuoted}},
+			Row:        map[string]interface{}{"a": "it's"},
+			Expected:   true,
+		},
+		{
+			Name: "numbers",
+			Conditions: []Condition{
+				{Operand1: "a", Operand1Type: OpField, Operator: Gt, Operand2: "1.5", Operand2Type: OpNumber},
+				{Operand1: "10", Operand1Type: OpNumber, Operator: Gte, Operand2: "b", Operand2Type: OpField},
+				{Operand1: "c", Operand1Type: OpField, Operator: Ne, Operand2: "0", Operand2Type: OpNumber},
+			},
+			Row:      map[string]interface{}{"a": 2, "b": float32(10), "c": uint8(1)},
+			Expected: true,
+		},
+		{
+			Name: "field to field",
+			Conditions: []Condition{
+				{Operand1: "a", Operand1Type: OpField, Operator: Lt, Operand2: "b", Operand2Type: OpField},
+				{Operand1: "c", Operand1Type: OpField, Operator: Lte, Operand2: "d", Operand2Type: OpField},
+			},
+			Row:      map[string]interface{}{"a": "x", "b": "y", "c": int64(3), "d": 2.5},
+			Expected: false,
+		},
+		{
+			Name: "IN and NOT IN",
+			Conditions: []Condition{
+				{Operand1: "a", Operand1Type: OpField, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "1", Type: OpNumber}, {Value: "2", Type: OpNumber}}},
+				{Operand1: "b", Operand1Type: OpField, Operator: NotIn, Operand2Type: OpList, Operand2List: []Operand{{Value: "x", Type: OpQuoted}, {Value: "c", Type: OpField}}},
+			},
+			Row:      map[string]interface{}{"a": 2, "b": "y", "c": "z"},
+			Expected: true,
+		},
+		{
+			Name: "LIKE with ESCAPE and NOT",
+			Conditions: []Condition{
+				{Operand1: "a", Operand1Type: OpField, Operator: Like, Operand2: "10!%%", Operand2Type: OpQuoted, Escape: "!"},
+				{Operand1: "b", Operand1Type: OpField, Operator: Like, Operand2: "x_", Operand2Type: OpQuoted, Not: true},
+			},
+			Row:      map[string]interface{}{"a": "10% off", "b": "xyz"},
+			Expected: true,
+		},
+		{
+			Name:       "NULL doesn't match",
+			Conditions: []Condition{{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpNumber, Not: true}},
+			Row:        map[string]interface{}{"a": nil},
+			Expected:   false,
+		},
+		{
+			Name:       "NOT IN with NULL doesn't match",
+			Conditions: []Condition{{Operand1: "a", Operand1Type: OpField, Operator: NotIn, Operand2Type: OpList, Operand2List: []Operand{{Value: "b", Type: OpField}}}},
+			Row:        map[string]interface{}{"a": 1},
+			Expected:   false,
+		},
+		{
+			Name:       "no conditions",
+			Conditions: nil,
+			Row:        map[string]interface{}{},
+			Expected:   true,
+		},
+		{
+			Name:       "mismatched types",
+			Conditions: []Condition{{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpNumber}},
+			Row:        map[string]interface{}{"a": "1"},
+			Err:        "cannot compare string with float64",
+		},
+		{
+			Name:       "unsupported value",
+			Conditions: []Condition{{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpNumber}},
+			Row:        map[string]interface{}{"a": true},
+			Err:        "unsupported value of type bool",
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			match, err := CompileConditions(tc.Conditions)
+			require.NoError(t, err)
+			matched, err := match(tc.Row)
+			if tc.Err != "" {
+				require.EqualError(t, err, tc.Err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, matched)
+		})
+	}
+
+	_, err := CompileConditions([]Condition{{Operand1: "lower(a)", Operand1Type: OpFunc, Operator: Eq, Operand2: "b", Operand2Type: OpQuoted}})
+	require.EqualError(t, err, "unsupported operand lower(a) of type OpFunc")
+	_, err = CompileConditions([]Condition{{Operand1: "a", Operand1Type: OpField, Operator: IsDistinctFrom, Operand2: "b", Operand2Type: OpQuoted}})
+	require.EqualError(t, err, "unsupported operator IsDistinctFrom in condition a IS DISTINCT FROM 'b'")
+	_, err = CompileConditions([]Condition{{Operand1: "a", Operand1Type: OpField, Operator: Like, Operand2: "x!", Operand2Type: OpQuoted, Escape: "!"}})
+	require.EqualError(t, err, "LIKE pattern ends with the escape character '!'")
+}
+
+func TestAddCondition(t *testing.T) {
+	q := &Query{Type: Delete, TableName: "a"}
+	q.AddEq("b", "1").AddCondition(Condition{Operand1: "c", Operand1Type: OpField, Operator: Gt, Operand2: "2", Operand2Type: OpNumber})
+	require.Equal(t, []Condition{
+		{Operand1: "b", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted},
+		{Operand1: "c", Operand1Type: OpField, Operator: Gt, Operand2: "2", Operand2Type: OpNumber},
+	}, q.Conditions)
+	require.Equal(t, "DELETE FROM 'a' WHERE b = '1' AND c > 2", q.String())
+}
+
+func TestFilterConditions(t *testing.T) {
+	q := Query{Type: Select, TableName: "a", Fields: []string{"b"}, Aliases: []string{""}}
+	q.AddEq("b", "1").AddEq("c", "2")
+	q.AddCondition(Condition{Operand1: "b", Operand1Type: OpField, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "3", Type: OpNumber}}})
+	onB := func(c Condition) bool { return c.Operand1 == "b" }
+
+	kept := q.FilterConditions(onB)
+	require.Equal(t, "SELECT b FROM 'a' WHERE b = '1' AND b IN (3)", kept.String())
+	kept.Conditions[1].Operand2List[0].Value = "4"
+	require.Equal(t, "3", q.Conditions[2].Operand2List[0].Value)
+	require.Len(t, q.Conditions, 3)
+
+	none := q.FilterConditions(func(Condition) bool { return false })
+	require.Nil(t, none.Conditions)
+	require.Equal(t, "SELECT b FROM 'a'", none.String())
+}
+
+func TestRenameColumn(t *testing.T) {
+	q := Query{
+		Type:        Update,
+		TableName:   "a",
+		Updates:     map[string]string{"b": "1", "c": "b", "d": "t.b"},
+		UpdateTypes: map[string]OperandType{"c": OpField, "d": OpField},
+		Conditions: []Condition{
+			{Operand1: "t.b", Operand1Type: OpField, Operator: Eq, Operand2: "b", Operand2Type: OpQuoted},
+			{Operand1: "c", Operand1Type: OpField, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "b", Type: OpField}, {Value: "b", Type: OpQuoted}}},
+			{Operand1: "bb", Operand1Type: OpField, Operator: Eq, Operand2: "ab", Operand2Type: OpField},
+		},
+	}
+	renamed := q.RenameColumn("b", "x")
+	require.Equal(t, Query{
+		Type:        Update,
+		TableName:   "a",
+		Updates:     map[string]string{"x": "1", "c": "x", "d": "t.x"},
+		UpdateTypes: map[string]OperandType{"c": OpField, "d": OpField},
+		Conditions: []Condition{
+			{Operand1: "t.x", Operand1Type: OpField, Operator: Eq, Operand2: "b", Operand2Type: OpQuoted},
+			{Operand1: "c", Operand1Type: OpField, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "x", Type: OpField}, {Value: "b", Type: OpQuoted}}},
+			{Operand1: "bb", Operand1Type: OpField, Operator: Eq, Operand2: "ab", Operand2Type: OpField},
+		},
+	}, renamed)
+	require.Equal(t, "1", q.Updates["b"], "original query changed")
+	require.Equal(t, "b", q.Conditions[1].Operand2List[0].Value, "original query changed")
+
+	s := Query{Type: Select, TableName: "a", Fields: []string{"b", "a.b", "c"}, Aliases: []string{"b", "", ""}}
+	require.Equal(t, []string{"x", "a.x", "c"}, s.RenameColumn("b", "x").Fields)
+	require.Equal(t, []string{"b", "", ""}, s.RenameColumn("b", "x").Aliases)
+}
+
+func TestNewSelect(t *testing.T) {
+	q := NewSelect("a", "b", "c").WithLimit(10).WithOffset(20).AddEq("b", "1")
+	require.Equal(t, "SELECT b, c FROM 'a' WHERE b = '1' LIMIT 10 OFFSET 20", q.String())
+	require.Equal(t, "SELECT * FROM 'a'", NewSelect("a").String())
+}
+
+func TestNewInsert(t *testing.T) {
+	q, err := NewInsert("a", "b", "c").
+		AddRow(Operand{Value: "1", Type: OpQuoted}, Operand{Value: "it's", Type: OpQuoted}).
+		AddRow(Operand{Value: "2", Type: OpQuoted}, Operand{Value: "", Type: OpQuoted}).
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, Query{Type: Insert, TableName: "a", Fields: []string{"b", "c"}, Inserts: [][]string{{"1", "it's"}, {"2", ""}}}, q)
+	require.Equal(t, "INSERT INTO 'a' (b, c) VALUES ('1', 'it''s'), ('2', '')", q.String())
+
+	_, err = NewInsert("a", "b", "c").AddRow(Operand{Value: "1", Type: OpQuoted}).Build()
+	require.EqualError(t, err, "row 1 has 1 values, expected 2")
+	_, err = NewInsert("a", "b").AddRow(Operand{Value: "1", Type: OpNumber}).Build()
+	require.EqualError(t, err, "row 1: value 1 must be quoted, not OpNumber")
+	_, err = NewInsert("a", "b").Build()
+	require.EqualError(t, err, "INSERT into 'a' needs at least one row")
+	_, err = NewInsert("a").AddRow().Build()
+	require.EqualError(t, err, "INSERT into 'a' needs at least one field")
+}
+
+func TestFlatten(t *testing.T) {
+	limit := int64(10)
+	ts := []Query{
+		{
+			Type:      Select,
+			TableName: "a",
+			Fields:    []string{"b", "c"},
+			Aliases:   []string{"", "d"},
+			Conditions: []Condition{
+				{Operand1: "b", Operand1Type: OpField, Operator: Gte, Operand2: "1", Operand2Type: OpNumber},
+				{Operand1: "c", Operand1Type: OpField, Operator: Gt, Quantifier: Any, Operand2Type: OpList, Operand2List: []Operand{{Value: "2", Type: OpQuoted}}, Not: true},
+				{
+					Operand1Type: OpTuple, Operand1List: []Operand{{Value: "b", Type: OpField}, {Value: "c", Type: OpField}},
+					Operator:     In,
+					Operand2Type: OpList, Operand2List: []Operand{{Type: OpTuple, Tuple: []Operand{{Value: "1", Type: OpQuoted}, {Value: "2", Type: OpNumber}}}},
+				},
+				{Operand1Type: OpList, Operand1List: []Operand{{Value: "b", Type: OpField}}, Operator: Match, Operand2: "x", Operand2Type: OpQuoted, MatchMode: "IN BOOLEAN MODE"},
+			},
+			Limit:    &limit,
+			Comments: []Comment{{Text: "-- c", Pos: 5}},
+		},
+		{
+			Type:        Update,
+			TableName:   "a",
+			Updates:     map[string]string{"b": "1", "c": "d"},
+			UpdateTypes: map[string]OperandType{"c": OpField},
+			Conditions:  []Condition{{Operand1: "e", Operand1Type: OpField, Operator: Like, Operand2: "x!%", Operand2Type: OpQuoted, Escape: "!"}},
+		},
+		{
+			Type:        Update,
+			TableName:   "a",
+			Updates:     map[string]string{"b": "1"},
+			UpdateCasts: map[string]string{"b": "int"},
+			Conditions: []Condition{
+				{Operand1: "c", Operand1Type: OpField, Operand1Cast: "text", Operator: Eq, Operand2: "2021-01-01", Operand2Type: OpQuoted, Operand2Cast: "date"},
+				{Operand1: "d", Operand1Type: OpField, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "1", Type: OpQuoted, Cast: "int"}}},
+			},
+		},
+		{Type: Insert, TableName: "a", Fields: []string{"b"}, Inserts: [][]string{{"1"}, {"2"}}},
+		{Type: Alter, TableName: "a", AlterActions: []AlterAction{{Action: AddColumn, Column: "b", ColumnType: "INT"}, {Action: DropColumn, Column: "c"}}},
+		{Type: Savepoint, SavepointName: "s"},
+		{
+			Type:      Select,
+			Fields:    []string{"s.b"},
+			Aliases:   []string{""},
+			FromQuery: &Query{Type: Select, TableName: "a", Fields: []string{"b"}, Aliases: []string{""}},
+			FromAlias: "s",
+		},
+	}
+	for _, q := range ts {
+		t.Run(q.String(), func(t *testing.T) {
+			f := q.Flatten()
+			u, err := f.Unflatten()
+			require.NoError(t, err)
+			require.Equal(t, q, u)
+		})
+	}
+
+	f := Query{Type: Select, TableName: "a", Fields: []string{"b"}}.Flatten()
+	require.Equal(t, "Select", f.Type)
+	f.Type = "Merge"
+	_, err := f.Unflatten()
+	require.EqualError(t, err, "unknown query type 'Merge'")
+}
+
+func TestConditionNormalize(t *testing.T) {
+	ts := []struct {
+		Condition Condition
+		Expected  Condition
+	}{
+		{
+			Condition: Condition{Operand1: "1", Operand1Type: OpQuoted, Operator: Eq, Operand2: "a", Operand2Type: OpField},
+			Expected:  Condition{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted},
+		},
+		{
+			Condition: Condition{Operand1: "1", Operand1Type: OpNumber, Operator: Lt, Operand2: "a", Operand2Type: OpField, Not: true},
+			Expected:  Condition{Operand1: "a", Operand1Type: OpField, Operator: Gt, Operand2: "1", Operand2Type: OpNumber, Not: true},
+		},
+		{
+			Condition: Condition{Operand1: "x'0f'", Operand1Type: OpHex, Operator: Gte, Operand2: "a", Operand2Type: OpField},
+			Expected:  Condition{Operand1: "a", Operand1Type: OpField, Operator: Lte, Operand2: "x'0f'", Operand2Type: OpHex},
+		},
+		{
+			Condition: Condition{Operand1: "1", Operand1Type: OpQuoted, Operand1Cast: "int", Operator: Lt, Operand2: "a", Operand2Type: OpField},
+			Expected:  Condition{Operand1: "a", Operand1Type: OpField, Operator: Gt, Operand2: "1", Operand2Type: OpQuoted, Operand2Cast: "int"},
+		},
+		{
+			Condition: Condition{Operand1: "a", Operand1Type: OpField, Operator: Lt, Operand2: "1", Operand2Type: OpQuoted},
+			Expected:  Condition{Operand1: "a", Operand1Type: OpField, Operator: Lt, Operand2: "1", Operand2Type: OpQuoted},
+		},
+		{
+			Condition: Condition{Operand1: "x%", Operand1Type: OpQuoted, Operator: Like, Operand2: "a", Operand2Type: OpField},
+			Expected:  Condition{Operand1: "x%", Operand1Type: OpQuoted, Operator: Like, Operand2: "a", Operand2Type: OpField},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Condition.String(), func(t *testing.T) {
+			require.Equal(t, tc.Expected, tc.Condition.Normalize())
+		})
+	}
+}
+
+func TestEqualityFilters(t *testing.T) {
+	q := Query{
+		Type:      Select,
+		TableName: "t",
+		Fields:    []string{"a"},
+		Conditions: []Condition{
+			{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted},
+			{Operand1: "2", Operand1Type: OpNumber, Operator: Eq, Operand2: "b", Operand2Type: OpField},
+			{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "3", Operand2Type: OpQuoted},
+			{Operand1: "c", Operand1Type: OpField, Operator: Gt, Operand2: "4", Operand2Type: OpNumber},
+			{Operand1: "d", Operand1Type: OpField, Operator: Eq, Operand2: "e", Operand2Type: OpField},
+			{Operand1: "f", Operand1Type: OpField, Operator: Eq, Operand2: "5", Operand2Type: OpNumber, Not: true},
+			{Operand1: "g", Operand1Type: OpField, Operator: Eq, Operand2: "?", Operand2Type: OpPlaceholder},
+			{Operand1: "h", Operand1Type: OpField, Operator: Eq, Operand2: "x", Operand2Type: OpQuoted, Collation: "nocase"},
+		},
+	}
+	require.Equal(t, map[string]Operand{
+		"a": {Value: "1", Type: OpQuoted},
+		"b": {Value: "2", Type: OpNumber},
+	}, q.EqualityFilters())
+	require.Empty(t, Query{Type: Select, TableName: "t", Fields: []string{"a"}}.EqualityFilters())
+}
+
+func TestConditionFields(t *testing.T) {
+	ts := []struct {
+		Condition Condition
+		Expected  []string
+	}{
+		{
+			Condition: Condition{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "t.b", Operand2Type: OpField},
+			Expected:  []string{"a", "t.b"},
+		},
+		{
+			Condition: Condition{Operand1: "1", Operand1Type: OpQuoted, Operator: Lt, Operand2: "lower(a)", Operand2Type: OpFunc},
+		},
+		{
+			Condition: Condition{
+				Operand1Type: OpTuple, Operand1List: []Operand{{Value: "a", Type: OpField}, {Value: "1", Type: OpNumber}},
+				Operator: In, Operand2Type: OpList,
+				Operand2List: []Operand{{Type: OpTuple, Tuple: []Operand{{Value: "b", Type: OpField}, {Value: "2", Type: OpNumber}}}},
+			},
+			Expected: []string{"a", "b"},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Condition.String(), func(t *testing.T) {
+			require.Equal(t, tc.Expected, tc.Condition.Fields())
+		})
+	}
+}
+
+func TestConditionIsConstant(t *testing.T) {
+	ts := []struct {
+		Condition Condition
+		Value     bool
+		OK        bool
+	}{
+		{Condition: Condition{Operand1: "1", Operand1Type: OpQuoted, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted}, Value: true, OK: true},
+		{Condition: Condition{Operand1: "1", Operand1Type: OpQuoted, Operator: Ne, Operand2: "1", Operand2Type: OpQuoted}, Value: false, OK: true},
+		{Condition: Condition{Operand1: "it's", Operand1Type: OpQuoted, Operator: Eq, Operand2: "it's", Operand2Type: OpQuoted}, Value: true, OK: true},
+		{Condition: Condition{Operand1: "it''s", Operand1Type: OpQuoted, Operator: Eq, Operand2: "it's", Operand2Type: OpQuoted}, Value: false, OK: true},
+		{Condition: Condition{Operand1: "1.0", Operand1Type: OpNumber, Operator: Eq, Operand2: "1", Operand2Type: OpNumber}, Value: true, OK: true},
+		{Condition: Condition{Operand1: "2", Operand1Type: OpNumber, Operator: Gt, Operand2: "10", Operand2Type: OpNumber}, Value: false, OK: true},
+		{Condition: Condition{Operand1: "2", Operand1Type: OpNumber, Operator: Gt, Operand2: "10", Operand2Type: OpNumber, Not: true}, Value: true, OK: true},
+		{
+			Condition: Condition{Operand1: "2", Operand1Type: OpNumber, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "1", Type: OpNumber}, {Value: "3", Type: OpNumber}}},
+			Value:     false, OK: true,
+		},
+		{
+			Condition: Condition{Operand1: "b", Operand1Type: OpQuoted, Operator: NotIn, Operand2Type: OpList, Operand2List: []Operand{{Value: "a", Type: OpQuoted}}},
+			Value:     true, OK: true,
+		},
+		{Condition: Condition{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted}},
+		{Condition: Condition{Operand1: "1", Operand1Type: OpQuoted, Operator: Eq, Operand2: "a", Operand2Type: OpField}},
+		{Condition: Condition{Operand1: "1", Operand1Type: OpQuoted, Operator: Eq, Operand2: "1", Operand2Type: OpNumber}},
+		{Condition: Condition{Operand1: "a", Operand1Type: OpQuoted, Operator: Eq, Operand2: "A", Operand2Type: OpQuoted, Collation: "utf8_general_ci"}},
+		{Condition: Condition{Operand1: "a", Operand1Type: OpQuoted, Operator: Like, Operand2: "a%", Operand2Type: OpQuoted}},
+		{
+			Condition: Condition{Operand1: "1", Operand1Type: OpNumber, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "1", Type: OpNumber}, {Value: "b", Type: OpField}}},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Condition.String(), func(t *testing.T) {
+			value, ok := tc.Condition.IsConstant()
+			require.Equal(t, tc.OK, ok)
+			require.Equal(t, tc.Value, value)
+		})
+	}
+}
+
+func TestConditionOperandAccessors(t *testing.T) {
+	accessors := []struct {
+		Name  string
+		Left  func(Condition) (string, bool)
+		Right func(Condition) (string, bool)
+		Type  OperandType
+	}{
+		{Name: "Field", Left: Condition.LeftField, Right: Condition.RightField, Type: OpField},
+		{Name: "String", Left: Condition.LeftString, Right: Condition.RightString, Type: OpQuoted},
+		{Name: "Number", Left: Condition.LeftNumber, Right: Condition.RightNumber, Type: OpNumber},
+	}
+	for _, a := range accessors {
+		t.Run(a.Name, func(t *testing.T) {
+			for i := range OperandTypeString {
+				opType := OperandType(i)
+				c := Condition{Operand1: "x", Operand1Type: opType, Operator: Eq, Operand2: "y", Operand2Type: opType}
+				value, ok := a.Left(c)
+				require.Equal(t, opType == a.Type, ok, "left %s", opType)
+				require.Equal(t, "x", value)
+				value, ok = a.Right(c)
+				require.Equal(t, opType == a.Type, ok, "right %s", opType)
+				require.Equal(t, "y", value)
+			}
+		})
+	}
+
+	c := Condition{Operand1: "a", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpNumber}
+	_, ok := c.RightField()
+	require.False(t, ok)
+	n, ok := c.RightNumber()
+	require.True(t, ok)
+	require.Equal(t, "1", n)
+}
+
+func TestWildcard(t *testing.T) {
+	ts := []struct {
+		Fields   []string
+		Wildcard bool
+		Has      bool
+	}{
+		{Fields: []string{"*"}, Wildcard: true, Has: true},
+		{Fields: []string{"a", "*"}, Wildcard: false, Has: true},
+		{Fields: []string{"t.*"}, Wildcard: false, Has: true},
+		{Fields: []string{"a", "count(*)"}, Wildcard: false, Has: false},
+		{Fields: nil, Wildcard: false, Has: false},
+	}
+	for _, tc := range ts {
+		t.Run(strings.Join(tc.Fields, ","), func(t *testing.T) {
+			q := Query{Type: Select, TableName: "a", Fields: tc.Fields}
+			require.Equal(t, tc.Wildcard, q.IsWildcardSelect())
+			require.Equal(t, tc.Has, q.HasWildcard())
+		})
+	}
+}
+
+func TestSortedOperands(t *testing.T) {
+	q := func(v string) Operand { return Operand{Value: v, Type: OpQuoted} }
+	n := func(v string) Operand { return Operand{Value: v, Type: OpNumber} }
+	ops := []Operand{q("b"), n("10"), q("a"), n("9"), q("b"), n("1.0"), n("1"), n("-2")}
+
+	require.Equal(t, []Operand{q("a"), q("b"), q("b"), n("-2"), n("1.0"), n("1"), n("9"), n("10")}, SortedOperands(ops, false))
+	require.Equal(t, []Operand{q("a"), q("b"), n("-2"), n("1.0"), n("9"), n("10")}, SortedOperands(ops, true))
+	require.Equal(t, q("b"), ops[0], "the operands must not be modified")
+
+	tuples := []Operand{
+		{Type: OpTuple, Tuple: []Operand{q("b"), n("1")}},
+		{Type: OpTuple, Tuple: []Operand{q("a"), n("2")}},
+		{Type: OpTuple, Tuple: []Operand{q("a"), n("1")}},
+	}
+	require.Equal(t, []Operand{tuples[2], tuples[1], tuples[0]}, SortedOperands(tuples, true))
+}
+
+func TestWarnings(t *testing.T) {
+	ts := []struct {
+		Name     string
+		Query    Query
+		Expected []string
+	}{
+		{
+			Name:     "unfiltered update",
+			Query:    Query{Type: Update, TableName: "a", Updates: map[string]string{"b": "1"}},
+			Expected: []string{"UPDATE without WHERE affects all rows"},
+		},
+		{
+			Name:     "unfiltered delete",
+			Query:    Query{Type: Delete, TableName: "a"},
+			Expected: []string{"DELETE without WHERE affects all rows"},
+		},
+		{
+			Name:     "select wildcard",
+			Query:    Query{Type: Select, TableName: "a", Fields: []string{"b", "t.*"}, Aliases: []string{"", ""}},
+			Expected: []string{"SELECT * depends on the table columns"},
+		},
+		{
+			Name: "literal conditions",
+			Query: Query{
+				Type:      Delete,
+				TableName: "a",
+				Conditions: []Condition{
+					{Operand1: "1", Operand1Type: OpQuoted, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted},
+					{Operand1: "b", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpNumber},
+					{Operand1: "2", Operand1Type: OpNumber, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "3", Type: OpNumber}}},
+					{Operand1: "2", Operand1Type: OpNumber, Operator: Eq, Operand2: "?", Operand2Type: OpPlaceholder},
+				},
+			},
+			Expected: []string{"condition '1' = '1' compares literals only", "condition 2 IN (3) compares literals only"},
+		},
+		{
+			Name: "no warnings",
+			Query: Query{
+				Type:       Select,
+				TableName:  "a",
+				Fields:     []string{"b"},
+				Aliases:    []string{""},
+				Conditions: []Condition{{Operand1: "b", Operand1Type: OpField, Operator: Eq, Operand2: "1", Operand2Type: OpQuoted}},
+			},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Expected, tc.Query.Warnings())
+		})
+	}
+}
+
+func TestToPrepared(t *testing.T) {
+	q := Query{
+		Type:        Update,
+		TableName:   "a",
+		Updates:     map[string]string{"b": "x", "c": "d", "e": "5"},
+		UpdateTypes: map[string]OperandType{"c": OpField, "e": OpNumber},
+		Conditions: []Condition{
+			{Operand1: "f", Operand1Type: OpField, Operator: In, Operand2Type: OpList, Operand2List: []Operand{{Value: "1", Type: OpQuoted}, {Value: "x'1F'", Type: OpHex}}},
+			{Operand1: "g", Operand1Type: OpField, Operator: Gt, Operand2: "INTERVAL '1 day'", Operand2Type: OpInterval},
+			{Operand1: "lower(h)", Operand1Type: OpFunc, Operator: Like, Operand2: "x%", Operand2Type: OpQuoted, Escape: "!"},
+		},
+	}
+	sql, args := q.ToPrepared(PlaceholderDollar)
+	require.Equal(t, "UPDATE 'a' SET b = $1, c = d, e = $2 WHERE f IN ($3, $4) AND g > INTERVAL '1 day' AND lower(h) LIKE $5 ESCAPE '!'", sql)
+	require.Equal(t, []Operand{
+		{Value: "x", Type: OpQuoted}, {Value: "5", Type: OpNumber}, {Value: "1", Type: OpQuoted}, {Value: "x'1F'", Type: OpHex}, {Value: "x%", Type: OpQuoted},
+	}, args)
+
+	insert := Query{Type: Insert, TableName: "a", Fields: []string{"b", "c"}, Inserts: [][]string{{"1", "2"}, {"3", "4"}}}
+	sql, args = insert.ToPrepared(PlaceholderQuestion)
+	require.Equal(t, "INSERT INTO 'a' (b, c) VALUES (?, ?), (?, ?)", sql)
+	require.Equal(t, []Operand{{Value: "1", Type: OpQuoted}, {Value: "2", Type: OpQuoted}, {Value: "3", Type: OpQuoted}, {Value: "4", Type: OpQuoted}}, args)
+
+	sql, args = Query{Type: Begin}.ToPrepared(PlaceholderQuestion)
+	require.Equal(t, "BEGIN", sql)
+	require.Empty(t, args)
+}
+
+func TestOperatorNegate(t *testing.T) {
+	for i := range OperatorString {
+		o := Operator(i)
+		negated, ok := o.Negate()
+		if o == UnknownOperator || o == Match {
+			require.False(t, ok, OperatorString[i])
+			continue
+		}
+		require.True(t, ok, OperatorString[i])
+		require.NotEqual(t, o, negated)
+		back, ok := negated.Negate()
+		require.True(t, ok)
+		require.Equal(t, o, back, "%s isn't negated back", OperatorString[i])
+	}
+	negated, _ := Gt.Negate()
+	require.Equal(t, Lte, negated)
+}
+
+func TestEnumStrings(t *testing.T) {
+	// count the constants declared for each enum type, so a new constant without a name fails
+	file, err := parser.ParseFile(token.NewFileSet(), "query.go", nil, 0)
+	require.NoError(t, err)
+	consts := map[string]int{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		typ := ""
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); ok {
+				typ = ident.Name
+			}
+			consts[typ] += len(value.Names)
+		}
+	}
+	enums := []struct {
+		Type   string
+		Names  []string
+		String func(i int) string
+	}{
+		{Type: "Type", Names: TypeString, String: func(i int) string { return Type(i).String() }},
+		{Type: "Operator", Names: OperatorString, String: func(i int) string { return Operator(i).String() }},
+		{Type: "Quantifier", Names: QuantifierString, String: func(i int) string { return Quantifier(i).String() }},
+		{Type: "OperandType", Names: OperandTypeString, String: func(i int) string { return OperandType(i).String() }},
+		{Type: "AlterActionType", Names: AlterActionString, String: func(i int) string { return AlterActionType(i).String() }},
+	}
+	for _, enum := range enums {
+		require.Equal(t, consts[enum.Type], len(enum.Names), "%s constants and names differ", enum.Type)
+		for i, name := range enum.Names {
+			require.Equal(t, name, enum.String(i))
+		}
+		require.Equal(t, "-1", enum.String(-1))
+		require.Equal(t, strconv.Itoa(len(enum.Names)), enum.String(len(enum.Names)))
+	}
+	require.Equal(t, len(OperatorString), len(operatorSymbol), "operators and symbols differ")
+}
@@ -0,0 +1,520 @@
+package query
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IdentifierQuote is the quoting style for identifiers emitted by Query.Format
+type IdentifierQuote int
+
+const (
+	// QuoteNone never quotes identifiers
+	QuoteNone IdentifierQuote = iota
+	// QuoteDouble quotes identifiers with double quotes, e.g. "select"
+	QuoteDouble
+	// QuoteBacktick quotes identifiers with backticks, e.g. `select`
+	QuoteBacktick
+)
+
+// keywords are the SQL keywords recognized by the parser, identifiers equal to them must be quoted. It's
+// the only keyword list, the parser reserves some of them as identifiers, see IsKeyword.
+var keywords = map[string]bool{
+	"AS": true, "SELECT": true, "INSERT": true, "INTO": true, "VALUES": true, "UPDATE": true,
+	"DELETE": true, "FROM": true, "WHERE": true, "SET": true, "AND": true, "IS": true, "NOT": true,
+	"DISTINCT": true, "IN": true, "LIKE": true, "ALTER": true, "TABLE": true, "ADD": true,
+	"DROP": true, "COLUMN": true, "ANY": true, "ALL": true, "ESCAPE": true,
+	"BEGIN": true, "COMMIT": true, "ROLLBACK": true, "SAVEPOINT": true, "TRANSACTION": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+	"INTERVAL": true, "ONLY": true, "LIMIT": true, "OFFSET": true,
+	"EXPLAIN": true, "DESCRIBE": true, "DESC": true, "OVER": true, "COLLATE": true,
+}
+
+// IsKeyword checks if word is a keyword recognized by the parser, ignoring case
+func IsKeyword(word string) bool {
+	return keywords[strings.ToUpper(word)]
+}
+
+// Keywords returns the keywords recognized by the parser, upper cased and sorted
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// String returns the SQL representation of the query. The result parses back to an equal Query.
+// It returns an empty string for UnknownType.
+func (q Query) String() string {
+	return formatter{parserSyntax: true}.query(q)
+}
+
+// Format returns the SQL representation of the query for another database. Table and field names are
+// emitted as identifiers, quoted with quote if they are keywords or contain special symbols.
+// It returns an empty string for UnknownType.
+func (q Query) Format(quote IdentifierQuote) string {
+	return formatter{quote: quote}.query(q)
+}
+
+// Minify returns the shortest SQL representation of the query, without the optional spaces around
+// commas, parentheses and comparison operators, e.g. SELECT a,b FROM 'c' WHERE a='1'. Like String,
+// the result parses back to an equal Query. It returns an empty string for UnknownType.
+func (q Query) Minify() string {
+	return formatter{parserSyntax: true, compact: true}.query(q)
+}
+
+// String returns the SQL representation of the condition, e.g. a = '1'
+func (c Condition) String() string {
+	var sb strings.Builder
+	formatter{parserSyntax: true}.condition(&sb, c)
+	return sb.String()
+}
+
+// Dump returns the SQL representation of the operand. Field names are quoted with quote if they are
+// keywords or contain special symbols, plain names like a_1 are returned as is.
+func (o Operand) Dump(quote IdentifierQuote) string {
+	var sb strings.Builder
+	f := formatter{quote: quote}
+	if o.Type == OpTuple {
+		f.list(&sb, o.Tuple)
+	} else {
+		f.operand(&sb, o.Value, o.Type)
+	}
+	return sb.String()
+}
+
+type formatter struct {
+	quote IdentifierQuote
+	// parserSyntax emits table names as quoted strings and identifiers as is, like the parser input
+	parserSyntax bool
+	// compact omits the spaces which aren't required, see Query.Minify
+	compact bool
+	// args, if not nil, collects the literals replaced by placeholders in style, see Query.ToPrepared
+	args  *[]Operand
+	style PlaceholderStyle
+}
+
+func (f formatter) query(q Query) string {
+	var sb strings.Builder
+	if q.Explain {
+		sb.WriteString("EXPLAIN ")
+	}
+	// a percentage can't be written with LIMIT, nor a limit without FROM, which LIMIT must follow
+	top := q.Type == Select && q.Limit != nil && (q.LimitPercent || (q.TableName == "" && q.FromQuery == nil))
+	switch q.Type {
+	case Select:
+		sb.WriteString("SELECT ")
+		if q.Distinct {
+			sb.WriteString("DISTINCT ")
+		}
+		if top {
+			sb.WriteString("TOP ")
+			sb.WriteString(strconv.FormatInt(*q.Limit, 10))
+			if q.LimitPercent {
+				sb.WriteString(" PERCENT")
+			}
+			sb.WriteByte(' ')
+		}
+		for i, field := range q.Fields {
+			if i > 0 {
+				f.comma(&sb)
+			}
+			f.name(&sb, field, fieldType(field))
+			if i < len(q.Aliases) && q.Aliases[i] != "" {
+				sb.WriteString(" AS ")
+				f.alias(&sb, q.Aliases[i])
+			}
+		}
+		if q.FromQuery != nil {
+			sb.WriteString(" FROM (")
+			sb.WriteString(f.query(*q.FromQuery))
+			sb.WriteString(") ")
+			f.identifier(&sb, q.FromAlias)
+		} else if q.TableName != "" {
+			sb.WriteString(" FROM ")
+			f.table(&sb, q.TableName, q.OnlyTable)
+		}
+	case Insert:
+		sb.WriteString("INSERT INTO ")
+		f.table(&sb, q.TableName, q.OnlyTable)
+		sb.WriteString(" (")
+		for i, field := range q.Fields {
+			if i > 0 {
+				f.comma(&sb)
+			}
+			f.name(&sb, field, OpField)
+		}
+		sb.WriteString(") VALUES ")
+		for i, row := range q.Inserts {
+			if i > 0 {
+				f.comma(&sb)
+			}
+			sb.WriteByte('(')
+			for j, value := range row {
+				if j > 0 {
+					f.comma(&sb)
+				}
+				f.operand(&sb, value, OpQuoted)
+			}
+			sb.WriteByte(')')
+		}
+	case Update:
+		sb.WriteString("UPDATE ")
+		f.table(&sb, q.TableName, q.OnlyTable)
+		sb.WriteString(" SET ")
+		// Updates is a map, sort for a stable output
+		fields := make([]string, 0, len(q.Updates))
+		for field := range q.Updates {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for i, field := range fields {
+			if i > 0 {
+				f.comma(&sb)
+			}
+			f.name(&sb, field, OpField)
+			f.operator(&sb, "=", true)
+			f.operand(&sb, q.Updates[field], q.UpdateType(field))
+			f.cast(&sb, q.UpdateCasts[field])
+		}
+		for i, table := range q.UpdateFrom {
+			if i == 0 {
+				sb.WriteString(" FROM ")
+			} else {
+				f.comma(&sb)
+			}
+			f.table(&sb, table, false)
+		}
+	case Delete:
+		sb.WriteString("DELETE FROM ")
+		f.table(&sb, q.TableName, q.OnlyTable)
+		for i, table := range q.DeleteUsing {
+			if i == 0 {
+				sb.WriteString(" USING ")
+			} else {
+				f.comma(&sb)
+			}
+			f.table(&sb, table, false)
+		}
+	case Alter:
+		sb.WriteString("ALTER TABLE ")
+		f.table(&sb, q.TableName, q.OnlyTable)
+		for i, a := range q.AlterActions {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if a.Action == AddColumn {
+				sb.WriteString(" ADD COLUMN ")
+				f.identifier(&sb, a.Column)
+				sb.WriteByte(' ')
+				sb.WriteString(a.ColumnType)
+			} else {
+				sb.WriteString(" DROP COLUMN ")
+				f.identifier(&sb, a.Column)
+			}
+		}
+	case Begin:
+		sb.WriteString("BEGIN")
+	case Commit:
+		sb.WriteString("COMMIT")
+	case Rollback:
+		sb.WriteString("ROLLBACK")
+	case Savepoint:
+		sb.WriteString("SAVEPOINT ")
+		f.identifier(&sb, q.SavepointName)
+	case Describe:
+		sb.WriteString("DESCRIBE ")
+		f.table(&sb, q.TableName, false)
+	default:
+		return ""
+	}
+	for i, c := range q.Conditions {
+		if i == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		f.condition(&sb, c)
+	}
+	if q.Limit != nil && !top {
+		sb.WriteString(" LIMIT ")
+		sb.WriteString(strconv.FormatInt(*q.Limit, 10))
+	} else if q.LimitParam != nil {
+		sb.WriteString(" LIMIT ")
+		sb.WriteString(q.LimitParam.Value)
+	}
+	if q.Offset != nil {
+		sb.WriteString(" OFFSET ")
+		sb.WriteString(strconv.FormatInt(*q.Offset, 10))
+	} else if q.OffsetParam != nil {
+		sb.WriteString(" OFFSET ")
+		sb.WriteString(q.OffsetParam.Value)
+	}
+	return sb.String()
+}
+
+func (f formatter) condition(sb *strings.Builder, c Condition) {
+	if c.Not {
+		sb.WriteString("NOT (")
+	}
+	if c.Operator == Match {
+		f.match(sb, c)
+	} else {
+		f.comparison(sb, c)
+	}
+	if c.Not {
+		sb.WriteByte(')')
+	}
+}
+
+// match writes a full-text search condition, e.g. MATCH (a, b) AGAINST ('text' IN BOOLEAN MODE)
+func (f formatter) match(sb *strings.Builder, c Condition) {
+	sb.WriteString("MATCH ")
+	f.list(sb, c.Operand1List)
+	sb.WriteString(" AGAINST (")
+	f.operand(sb, c.Operand2, c.Operand2Type)
+	if c.MatchMode != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(c.MatchMode)
+	}
+	sb.WriteByte(')')
+}
+
+func (f formatter) comparison(sb *strings.Builder, c Condition) {
+	if c.Operand1Type == OpTuple {
+		f.list(sb, c.Operand1List)
+	} else {
+		f.operand(sb, c.Operand1, c.Operand1Type)
+		f.cast(sb, c.Operand1Cast)
+	}
+	// a JSON path may end with a symbol, e.g. a->b, keep it apart from the operator
+	symbolic := isComparisonOperator(c.Operator) && c.Operand1Type != OpJSONPath && c.Operand2Type != OpJSONPath
+	f.operator(sb, c.Operator.Symbol(), symbolic)
+	switch c.Quantifier {
+	case Any:
+		sb.WriteString("ANY ")
+	case All:
+		sb.WriteString("ALL ")
+	}
+	if c.Operand2Type == OpList {
+		f.list(sb, c.Operand2List)
+	} else {
+		f.operand(sb, c.Operand2, c.Operand2Type)
+		f.cast(sb, c.Operand2Cast)
+	}
+	if c.Collation != "" {
+		sb.WriteString(" COLLATE ")
+		sb.WriteString(c.Collation)
+	}
+	if c.Escape != "" {
+		sb.WriteString(" ESCAPE ")
+		writeQuoted(sb, c.Escape)
+	}
+}
+
+// comma writes the separator of list elements
+func (f formatter) comma(sb *strings.Builder) {
+	if f.compact {
+		sb.WriteByte(',')
+	} else {
+		sb.WriteString(", ")
+	}
+}
+
+// operator writes an operator surrounded by spaces. A symbolic operator like = needs no spaces when
+// compact, unlike keywords like LIKE.
+func (f formatter) operator(sb *strings.Builder, symbol string, symbolic bool) {
+	if f.compact && symbolic {
+		sb.WriteString(symbol)
+		return
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(symbol)
+	sb.WriteByte(' ')
+}
+
+// isComparisonOperator checks if o is written with symbols, e.g. >=
+func isComparisonOperator(o Operator) bool {
+	return o >= Eq && o <= Lte
+}
+
+// list writes a parenthesized list of operands, e.g. ('1', '2')
+func (f formatter) list(sb *strings.Builder, ops []Operand) {
+	sb.WriteByte('(')
+	for i, op := range ops {
+		if i > 0 {
+			f.comma(sb)
+		}
+		if op.Type == OpTuple {
+			f.list(sb, op.Tuple)
+		} else {
+			f.operand(sb, op.Value, op.Type)
+			f.cast(sb, op.Cast)
+		}
+	}
+	sb.WriteByte(')')
+}
+
+// cast writes the Postgres cast of an operand, e.g. ::date, if typeName isn't empty
+func (f formatter) cast(sb *strings.Builder, typeName string) {
+	if typeName != "" {
+		sb.WriteString("::")
+		sb.WriteString(typeName)
+	}
+}
+
+func (f formatter) operand(sb *strings.Builder, value string, opType OperandType) {
+	if f.args != nil {
+		switch opType {
+		case OpQuoted, OpNumber, OpHex, OpBit:
+			f.bind(sb, value, opType)
+			return
+		}
+	}
+	switch opType {
+	case OpQuoted:
+		writeQuoted(sb, value)
+	case OpField:
+		f.name(sb, value, OpField)
+	default:
+		sb.WriteString(value)
+	}
+}
+
+// alias writes a field alias. The parser syntax has no quoted identifiers, but it accepts a double
+// quoted alias, e.g. "My Column".
+func (f formatter) alias(sb *strings.Builder, name string) {
+	if f.parserSyntax && needsQuoting(name) {
+		f = formatter{quote: QuoteDouble}
+	}
+	f.identifier(sb, name)
+}
+
+func (f formatter) table(sb *strings.Builder, name string, only bool) {
+	if only {
+		sb.WriteString("ONLY ")
+	}
+	if f.parserSyntax {
+		writeQuoted(sb, name)
+	} else {
+		f.identifier(sb, name)
+	}
+}
+
+// identifier writes a field or table name, quoted if required. The * wildcard, function calls and
+// CASE expressions are written as is.
+func (f formatter) identifier(sb *strings.Builder, name string) {
+	var quote byte
+	switch f.quote {
+	case QuoteDouble:
+		quote = '"'
+	case QuoteBacktick:
+		quote = '`'
+	}
+	if f.parserSyntax || quote == 0 || name == "*" || strings.HasSuffix(name, ")") || isCase(name) || !needsQuoting(name) {
+		sb.WriteString(name)
+		return
+	}
+	sb.WriteByte(quote)
+	for i := 0; i < len(name); i++ {
+		if name[i] == quote {
+			sb.WriteByte(quote)
+		}
+		sb.WriteByte(name[i])
+	}
+	sb.WriteByte(quote)
+}
+
+// name writes a column name, or a SELECTed field of type opType, see fieldType. In the parser syntax, the
+// parts of an OpField name which are keywords or aren't plain identifiers are double quoted, so the name
+// parses back, e.g. "My Schema".users."select". Other fields are written as is.
+func (f formatter) name(sb *strings.Builder, name string, opType OperandType) {
+	if f.parserSyntax && opType == OpField {
+		writeQuotedName(sb, name)
+		return
+	}
+	f.identifier(sb, name)
+}
+
+// fieldType returns the type of a SELECTed field, like the parser reads it: OpFunc for a function call,
+// window functions included, OpExpr for a CASE expression, OpJSONPath for a JSON access chain, OpUnknown
+// for the * wildcard, e.g. * or t.*, and OpField for a column.
+func fieldType(field string) OperandType {
+	switch {
+	case field == "*" || strings.HasSuffix(field, ".*"):
+		return OpUnknown
+	case strings.HasSuffix(field, ")"):
+		return OpFunc
+	case isCase(field):
+		return OpExpr
+	case strings.Contains(field, "->") || strings.Contains(field, "#>"):
+		return OpJSONPath
+	}
+	return OpField
+}
+
+// writeQuotedName writes a name with the parts which are keywords or aren't plain identifiers double quoted,
+// e.g. "My Schema".users."select". A quote in a part is doubled.
+func writeQuotedName(sb *strings.Builder, name string) {
+	for i, part := range strings.Split(name, ".") {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		if !needsQuoting(part) {
+			sb.WriteString(part)
+			continue
+		}
+		sb.WriteByte('"')
+		sb.WriteString(strings.ReplaceAll(part, `"`, `""`))
+		sb.WriteByte('"')
+	}
+}
+
+// isCase checks if name is a CASE ... END expression
+func isCase(name string) bool {
+	upper := strings.ToUpper(name)
+	return strings.HasPrefix(upper, "CASE ") && strings.HasSuffix(upper, " END")
+}
+
+// needsQuoting checks if name is a keyword or isn't a plain identifier, like a_1
+func needsQuoting(name string) bool {
+	return keywords[strings.ToUpper(name)] || !isPlainIdentifier(name)
+}
+
+// isPlainIdentifier checks if name has only letters, digits and underscores, and doesn't start with a digit
+func isPlainIdentifier(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeQuoted writes value as a quoted string. The value is raw text, like the parser returns it: quotes are
+// doubled and a backslash is escaped before a quote, a backslash or the end, so no value can terminate the
+// string early, with or without backslash escapes, e.g. the backslash of \' OR 1=1 -- is escaped.
+func writeQuoted(sb *strings.Builder, value string) {
+	sb.WriteByte('\'')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\':
+			if i+1 == len(value) || value[i+1] == '\\' || value[i+1] == '\'' {
+				sb.WriteByte(c)
+			}
+			sb.WriteByte(c)
+		case '\'':
+			sb.WriteString("''")
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('\'')
+}
@@ -1,16 +1,34 @@
 package sqlparser
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/msaf1980/sqlparser/query"
 )
 
+var (
+	// ErrEmptyQuery is returned for a query without a query type, e.g. an empty string
+	ErrEmptyQuery = query.ErrEmptyType
+	// ErrNoTable is returned for a query without a table name
+	ErrNoTable = errors.New("table name cannot be empty")
+	// ErrEmptyWhere is returned for a WHERE without conditions
+	ErrEmptyWhere = errors.New("at WHERE: empty WHERE clause")
+)
+
+// ErrorWithPos is a parse error with the position in the query, where it was detected.
+// It wraps one of the Err sentinel errors if the error has a well-known cause, see errors.Is.
 type ErrorWithPos struct {
-	msg string
-	pos int
+	msg   string
+	pos   int
+	cause error
 }
 
 func newError(pos int, msg string) *ErrorWithPos {
@@ -20,6 +38,15 @@ func newError(pos int, msg string) *ErrorWithPos {
 	}
 }
 
+// newCauseError returns an error with the message of cause, wrapping it
+func newCauseError(pos int, cause error) *ErrorWithPos {
+	return &ErrorWithPos{
+		msg:   cause.Error(),
+		pos:   pos,
+		cause: cause,
+	}
+}
+
 func newErrorf(pos int, format string, a ...interface{}) *ErrorWithPos {
 	return &ErrorWithPos{
 		msg: fmt.Sprintf(format, a...),
@@ -27,6 +54,11 @@ func newErrorf(pos int, format string, a ...interface{}) *ErrorWithPos {
 	}
 }
 
+// IsZero checks if e is the zero value, i.e. no error, e.g. the error of ParsePartial for a parsed sql
+func (e ErrorWithPos) IsZero() bool {
+	return e.msg == ""
+}
+
 func (e *ErrorWithPos) Error() string {
 	return e.msg
 }
@@ -35,6 +67,11 @@ func (e *ErrorWithPos) Pos() int {
 	return e.pos
 }
 
+// Unwrap returns the cause of the error, nil if it isn't one of the Err sentinel errors
+func (e *ErrorWithPos) Unwrap() error {
+	return e.cause
+}
+
 func (e *ErrorWithPos) PrintPosError(sql string, w io.Writer) {
 	fmt.Fprintln(w, sql)
 	fmt.Fprintln(w, strings.Repeat(" ", e.pos)+"^")
@@ -42,21 +79,71 @@ func (e *ErrorWithPos) PrintPosError(sql string, w io.Writer) {
 }
 
 // Parse takes a string representing a SQL query and parses it into a query.Query struct. It may fail.
+// A leading UTF-8 BOM and surrounding whitespace are ignored.
 func Parse(sql string) (query.Query, error) {
-	sql = strings.TrimSpace(sql)
-	return (&parser{
-		sql:      sql,
-		sqlUpper: strings.ToUpper(sql),
-		step:     stepType,
-	}).parse()
+	return ParseWithOptions(sql, Options{})
+}
+
+// ParseWithOptions is like Parse, but the parser behavior is changed by opts.
+func ParseWithOptions(sql string, opts Options) (query.Query, error) {
+	return newParser(sql, opts).parse()
+}
+
+// ParsePartial is like Parse, but intended for incomplete input, e.g. from an editor on every keystroke.
+// The query parsed so far is always returned, even on error. The error position is where parsing stopped.
+// The error is a value, the zero value if the sql was parsed, which is checked with IsZero.
+func ParsePartial(sql string) (query.Query, ErrorWithPos) {
+	p := newParser(sql, Options{})
+	q, err := p.parse()
+	if err == nil {
+		return q, ErrorWithPos{}
+	}
+	if errPos, ok := err.(*ErrorWithPos); ok {
+		return q, *errPos
+	}
+	return q, *newError(p.offset+p.i, err.Error())
+}
+
+// NextExpected returns the keywords and symbols valid after the (possibly incomplete) sql, e.g. AS, comma
+// and FROM after "SELECT a". It's intended for autocomplete, field names and values are not suggested.
+func NextExpected(sql string) []string {
+	sql = strings.TrimRightFunc(sql, unicode.IsSpace)
+	var expected []string
+	for _, token := range suggestedTokens {
+		// the token is valid if the parser didn't stop before the end of the input
+		candidate := sql + " " + token
+		if _, err := ParsePartial(candidate); err.IsZero() || err.Pos() >= len(candidate) {
+			expected = append(expected, token)
+		}
+	}
+	return expected
+}
+
+func newParser(sql string, opts Options) *parser {
+	trimmed := strings.TrimLeftFunc(strings.TrimPrefix(sql, bom), unicode.IsSpace)
+	offset := len(sql) - len(trimmed)
+	sql = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	return &parser{
+		sql:          sql,
+		sqlUpper:     strings.ToUpper(sql),
+		step:         stepType,
+		opts:         opts,
+		offset:       offset,
+		quotedIdents: opts.stringQuote() != '"' && strings.IndexByte(sql, '"') >= 0,
+	}
 }
 
 // ParseMany takes a string slice representing many SQL queries and parses them into a query.Query struct slice.
 // It may fail. If it fails, it will stop at the first failure.
 func ParseMany(sqls []string) ([]query.Query, error) {
+	return ParseManyWithOptions(sqls, Options{})
+}
+
+// ParseManyWithOptions is like ParseMany, but the parser behavior is changed by opts.
+func ParseManyWithOptions(sqls []string, opts Options) ([]query.Query, error) {
 	qs := []query.Query{}
 	for _, sql := range sqls {
-		q, err := Parse(sql)
+		q, err := parseStatement(sql, opts)
 		if err != nil {
 			return qs, err
 		}
@@ -65,10 +152,119 @@ func ParseMany(sqls []string) ([]query.Query, error) {
 	return qs, nil
 }
 
+// ParseScript splits script into statements separated by semicolons and parses them, like ParseMany.
+// Semicolons in quoted strings, double quoted or backtick quoted identifiers and comments don't separate
+// statements. Empty statements, e.g. after the last semicolon, are skipped. Error positions are relative
+// to the script.
+func ParseScript(script string) ([]query.Query, error) {
+	return ParseScriptWithOptions(script, Options{})
+}
+
+// ParseScriptWithOptions is like ParseScript, but the parser behavior is changed by opts.
+func ParseScriptWithOptions(script string, opts Options) ([]query.Query, error) {
+	qs, _, err := parseScript(script, opts)
+	return qs, err
+}
+
+// ParseScriptWithOffsets is like ParseScript, but also returns the span of each parsed statement in
+// script, without the surrounding whitespace and the separating semicolon.
+func ParseScriptWithOffsets(script string) ([]query.Query, []query.Span, error) {
+	return parseScript(script, Options{})
+}
+
+func parseScript(script string, opts Options) ([]query.Query, []query.Span, error) {
+	qs := []query.Query{}
+	spans := []query.Span{}
+	for _, span := range splitScript(script, opts.stringQuote()) {
+		q, err := parseStatement(script[span.Start:span.End], opts)
+		if err != nil {
+			if errPos, ok := err.(*ErrorWithPos); ok {
+				errPos.pos += span.Start
+			}
+			return qs, spans, err
+		}
+		qs = append(qs, q)
+		spans = append(spans, span)
+	}
+	return qs, spans, nil
+}
+
+// splitScript splits script at semicolons outside of quotes and comments. Statements with only
+// whitespace and comments are skipped, surrounding whitespace isn't included in the spans. Like single
+// quotes, stringQuote delimits strings with backslash escapes, if not 0.
+func splitScript(script string, stringQuote byte) []query.Span {
+	var stmts []query.Span
+	start := 0
+	hasCode := false
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == ';':
+			if hasCode {
+				stmts = append(stmts, trimSpan(script, start, i))
+			}
+			start = i + 1
+			hasCode = false
+		case c == '\'', c == '"', c == '`':
+			// a doubled quote is skipped as two adjacent quoted parts
+			hasCode = true
+			for i++; i < len(script) && script[i] != c; i++ {
+				if script[i] == '\\' && (c == '\'' || c == stringQuote) {
+					// escaped symbol
+					i++
+				}
+			}
+		case strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case !isSpace(c):
+			hasCode = true
+		}
+	}
+	if hasCode {
+		stmts = append(stmts, trimSpan(script, start, len(script)))
+	}
+	return stmts
+}
+
+// trimSpan returns the span of script[start:end] without leading and trailing whitespace
+func trimSpan(script string, start, end int) query.Span {
+	for start < end && isSpace(script[start]) {
+		start++
+	}
+	for end > start && isSpace(script[end-1]) {
+		end--
+	}
+	return query.Span{Start: start, End: end}
+}
+
+// parseStatement parses a single statement of a batch, reporting it to opts.OnStatement
+func parseStatement(sql string, opts Options) (query.Query, error) {
+	if opts.OnStatement == nil {
+		return ParseWithOptions(sql, opts)
+	}
+	start := time.Now()
+	q, err := ParseWithOptions(sql, opts)
+	opts.OnStatement(sql, q, err, time.Since(start))
+	return q, err
+}
+
+// bom is the UTF-8 byte order mark, sometimes found at the start of SQL files
+const bom = "\ufeff"
+
 type step int
 
 const (
 	stepType step = iota
+	stepSelectDistinct
 	stepSelectField
 	stepSelectFrom
 	stepSelectComma
@@ -88,12 +284,25 @@ const (
 	stepUpdateEquals
 	stepUpdateValue
 	stepUpdateComma
+	stepUpdateFrom
+	stepDeleteUsing
 	stepDeleteFromTable
 	stepWhere
 	stepWhereField
 	stepWhereOperator
 	stepWhereValue
 	stepWhereAnd
+	stepAlterTable
+	stepAlterAction
+	stepAlterColumn
+	stepAlterColumnType
+	stepAlterComma
+	stepTransaction
+	stepSavepointName
+	stepDescribeTable
+	stepTableName
+	stepLimit
+	stepEnd
 )
 
 type parser struct {
@@ -101,20 +310,34 @@ type parser struct {
 	len             int
 	peeked          string
 	peekQuoted      bool
+	quotedName      bool // the peeked token is a name with double quoted parts, see peekQuotedNameWithLength
+	quotedIdents    bool // the query has double quotes and they delimit identifiers
 	sql             string
 	sqlUpper        string
 	step            step
 	query           query.Query
 	err             error
 	nextUpdateField string
+	opts            Options
+	whereParens     int
+	offset          int      // length of the ignored input prefix, added to error positions
+	insertValues    []string // INSERT values allocated for all rows at once, sliced per row
+	insertRowStart  int      // position of the opening parens of the current INSERT row
 }
 
 func (p *parser) parse() (query.Query, error) {
+	if quote := p.opts.StringQuote; quote != 0 && quote != '\'' && quote != '"' && quote != '`' {
+		return p.query, newErrorf(0, "unsupported string quote %q", quote)
+	}
+	p.popWhitespace() // leading comments
 	q, err := p.doParse()
 	p.err = err
 	if p.err == nil {
 		p.err = p.validate()
 	}
+	if errPos, ok := p.err.(*ErrorWithPos); ok {
+		errPos.pos += p.offset
+	}
 	return q, p.err
 }
 
@@ -129,7 +352,7 @@ func (p *parser) doParse() (query.Query, error) {
 			switch s {
 			case "SELECT":
 				p.query.Type = query.Select
-				p.step = stepSelectField
+				p.step = stepSelectDistinct
 			case "INSERT":
 				p.pop()
 				s = p.peek(true)
@@ -150,24 +373,116 @@ func (p *parser) doParse() (query.Query, error) {
 				}
 				p.query.Type = query.Delete
 				p.step = stepDeleteFromTable
+			case "ALTER":
+				p.pop()
+				s = p.peek(true)
+				if s != "TABLE" {
+					return p.query, newErrorf(p.i, "at ALTER: expected TABLE, got %s", s)
+				}
+				p.query.Type = query.Alter
+				p.step = stepAlterTable
+			case "BEGIN":
+				p.query.Type = query.Begin
+				p.step = stepTransaction
+			case "COMMIT":
+				p.query.Type = query.Commit
+				p.step = stepTransaction
+			case "ROLLBACK":
+				p.query.Type = query.Rollback
+				p.step = stepTransaction
+			case "SAVEPOINT":
+				p.query.Type = query.Savepoint
+				p.step = stepSavepointName
+			case "TABLE":
+				// TABLE name is a shorthand for SELECT * FROM name
+				if p.opts.Dialect != DialectPostgres {
+					return p.query, newError(p.i, "at TABLE: TABLE is only supported by the Postgres dialect")
+				}
+				p.query.Type = query.Select
+				p.query.Fields = []string{"*"}
+				p.query.Aliases = []string{""}
+				p.step = stepTableName
+			case "DESCRIBE", "DESC":
+				p.query.Type = query.Describe
+				p.step = stepDescribeTable
+			case "EXPLAIN":
+				if p.query.Explain {
+					return p.query, newError(p.i, "invalid query type")
+				}
+				// the explained statement follows, parse it from stepType again
+				p.query.Explain = true
 			default:
 				return p.query, newError(p.i, "invalid query type")
 			}
 			p.pop()
+		case stepSelectDistinct:
+			if p.peek(true) == "DISTINCT" {
+				p.query.Distinct = true
+				p.pop()
+			}
+			if p.opts.Dialect == DialectSQLServer && p.peek(true) == "TOP" {
+				p.pop()
+				parens := p.peek(false) == "("
+				if parens {
+					p.pop()
+				}
+				limit, err := strconv.ParseInt(p.peek(false), 10, 64)
+				if err != nil || limit < 0 || p.peekQuoted {
+					return p.query, newError(p.i, "at SELECT: expected number after TOP")
+				}
+				p.query.Limit = &limit
+				p.pop()
+				if parens {
+					if p.peek(false) != ")" {
+						return p.query, newError(p.i, "at SELECT: expected closing parens")
+					}
+					p.pop()
+				}
+				if p.peek(true) == "PERCENT" {
+					p.query.LimitPercent = true
+					p.pop()
+				}
+			}
+			p.step = stepSelectField
 		case stepSelectField:
-			identifier := p.peek(false)
-			if isId, _ := isIdentifierOrAsterisk(identifier); !isId {
-				return p.query, newError(p.i, "at SELECT: expected field to SELECT")
+			start := p.i
+			identifier, err := p.peekJSONPath("at SELECT")
+			if err == nil && identifier == "" {
+				identifier, err = p.peekCase("at SELECT")
+			}
+			if err != nil {
+				return p.query, err
+			} else if identifier == "" {
+				identifier = p.peek(false)
+				if end := p.i + len(identifier); !p.peekQuoted && end < len(p.sql) && p.sql[end] == '(' && parensEnd(p.sql, end) < 0 {
+					return p.query, newError(end, "at SELECT: unbalanced parentheses in expression")
+				}
+				if isId, _ := isIdentifierOrAsterisk(identifier); !isId && !p.isQualifiedField(identifier) {
+					return p.query, newError(p.i, "at SELECT: expected field to SELECT")
+				}
 			}
 			p.query.Fields = append(p.query.Fields, identifier)
 			p.pop()
+			if identifierType(identifier) == query.OpFunc && p.peek(true) == "OVER" {
+				window, err := p.parseOver()
+				if err != nil {
+					return p.query, err
+				}
+				// the field is the function call with its window, e.g. row_number() OVER (ORDER BY a)
+				window.Field = len(p.query.Fields) - 1
+				p.query.Fields[window.Field] = strings.TrimRightFunc(p.sql[start:p.i], unicode.IsSpace)
+				p.query.Windows = append(p.query.Windows, window)
+			}
 			maybeFrom := p.peek(true)
 			if maybeFrom == "AS" {
 				// alias
 				p.pop()
-				alias := p.peek(false)
-				if isId, _ := isIdentifierOrAsterisk(alias); !isId {
-					return p.query, newErrorf(p.i, "at AS: expected alias for %s", identifier)
+				alias, quoted := p.peekQuotedAlias()
+				if !quoted {
+					alias = p.peek(false)
+					if isId, _ := isIdentifierOrAsterisk(alias); !isId {
+						return p.query, newErrorf(p.i, "at AS: expected alias for %s", identifier)
+					}
 				}
 				p.query.Aliases = append(p.query.Aliases, alias)
 				p.pop()
@@ -186,6 +501,10 @@ func (p *parser) doParse() (query.Query, error) {
 				return p.query, newError(p.i, "at SELECT: expected comma or FROM")
 			}
 			p.pop()
+			if p.opts.AllowTrailingCommas && p.peek(true) == "FROM" && !p.peekQuoted {
+				p.step = stepSelectFrom
+				continue
+			}
 			p.step = stepSelectField
 		case stepSelectFrom:
 			fromRWord := p.peek(true)
@@ -195,6 +514,16 @@ func (p *parser) doParse() (query.Query, error) {
 			p.pop()
 			p.step = stepSelectFromTable
 		case stepSelectFromTable:
+			if p.peek(false) == "(" && !p.peekQuoted {
+				if err := p.parseDerivedTable(); err != nil {
+					return p.query, err
+				}
+				p.step = stepWhere
+				continue
+			}
+			if err := p.popOnly("at SELECT"); err != nil {
+				return p.query, err
+			}
 			tableName := p.peek(false)
 			if len(tableName) == 0 {
 				return p.query, newError(p.i, "at SELECT: expected quoted table name")
@@ -211,14 +540,28 @@ func (p *parser) doParse() (query.Query, error) {
 			p.pop()
 			p.step = stepInsertFieldsOpeningParens
 		case stepDeleteFromTable:
+			if err := p.popOnly("at DELETE FROM"); err != nil {
+				return p.query, err
+			}
 			tableName := p.peek(false)
 			if len(tableName) == 0 {
 				return p.query, newError(p.i, "at DELETE FROM: expected quoted table name")
 			}
 			p.query.TableName = tableName
 			p.pop()
+			if p.peek(true) == "USING" && !p.peekQuoted {
+				if p.opts.Dialect != DialectPostgres {
+					return p.query, newError(p.i, "at DELETE FROM: USING is only supported by the Postgres dialect")
+				}
+				p.pop()
+				p.step = stepDeleteUsing
+				continue
+			}
 			p.step = stepWhere
 		case stepUpdateTable:
+			if err := p.popOnly("at UPDATE"); err != nil {
+				return p.query, err
+			}
 			tableName := p.peek(false)
 			if len(tableName) == 0 {
 				return p.query, newError(p.i, "at UPDATE: expected quoted table name")
@@ -235,9 +578,12 @@ func (p *parser) doParse() (query.Query, error) {
 			p.step = stepUpdateField
 		case stepUpdateField:
 			identifier := p.peek(false)
-			if isId, _ := isIdentifier(identifier); !isId {
+			if isId, _ := isIdentifier(identifier); !isId && !p.quotedName {
 				return p.query, newError(p.i, "at UPDATE: expected at least one field to update")
 			}
+			if _, ok := p.query.Updates[identifier]; ok {
+				return p.query, newErrorf(p.i, "at UPDATE: duplicate assignment to column '%s'", identifier)
+			}
 			p.nextUpdateField = identifier
 			p.pop()
 			p.step = stepUpdateEquals
@@ -249,30 +595,93 @@ func (p *parser) doParse() (query.Query, error) {
 			p.pop()
 			p.step = stepUpdateValue
 		case stepUpdateValue:
-			quotedValue := p.peekQuotedString(false)
+			value, opType, err := p.peekLiteral("at UPDATE")
+			if err != nil {
+				return p.query, err
+			}
+			if value == "" {
+				value = p.peek(false)
+			}
 			if p.len == 0 {
 				return p.query, newError(p.i, "at UPDATE: expected quoted value")
 			}
-			p.query.Updates[p.nextUpdateField] = quotedValue
-			p.nextUpdateField = ""
+			if !p.peekQuoted {
+				// column reference, e.g. SET a = b, number, binary literal or placeholder
+				if opType == query.OpUnknown {
+					opType = query.OpNumber
+					if isId, isNumber := isIdentifier(value); isId || p.isQualifiedField(value) {
+						opType = identifierType(value)
+					} else if !isNumber {
+						return p.query, newError(p.i, "at UPDATE: expected quoted value")
+					}
+				}
+				switch upper := strings.ToUpper(value); upper {
+				case "NULL":
+					value, opType = upper, query.OpNull
+				case "TRUE", "FALSE":
+					value, opType = upper, query.OpBool
+				}
+				if p.query.UpdateTypes == nil {
+					p.query.UpdateTypes = map[string]query.OperandType{}
+				}
+				p.query.UpdateTypes[p.nextUpdateField] = opType
+			}
+			p.query.Updates[p.nextUpdateField] = value
 			p.pop()
+			cast, err := p.popCast("at UPDATE")
+			if err != nil {
+				return p.query, err
+			}
+			if cast != "" {
+				if p.query.UpdateCasts == nil {
+					p.query.UpdateCasts = map[string]string{}
+				}
+				p.query.UpdateCasts[p.nextUpdateField] = cast
+			}
+			p.nextUpdateField = ""
 			maybeWhere := p.peek(true)
 			if maybeWhere == "WHERE" {
 				p.step = stepWhere
 				continue
 			}
+			if maybeWhere == "FROM" && !p.peekQuoted {
+				if p.opts.Dialect != DialectPostgres {
+					return p.query, newError(p.i, "at UPDATE: FROM is only supported by the Postgres dialect")
+				}
+				p.pop()
+				p.step = stepUpdateFrom
+				continue
+			}
 			p.step = stepUpdateComma
 		case stepUpdateComma:
 			commaRWord := p.peek(false)
 			if commaRWord != "," {
-				return p.query, newError(p.i, "at UPDATE: expected ','")
+				return p.query, p.trailingError(newError(p.i, "at UPDATE: expected ','"))
 			}
 			p.pop()
 			p.step = stepUpdateField
+		case stepUpdateFrom:
+			tables, err := p.popTableList("at UPDATE FROM")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.UpdateFrom = tables
+			p.step = stepWhere
+		case stepDeleteUsing:
+			tables, err := p.popTableList("at DELETE USING")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.DeleteUsing = tables
+			p.step = stepWhere
 		case stepWhere:
 			whereRWord := p.peek(true)
+			if isLimitClause(whereRWord) && p.query.Type == query.Select {
+				p.step = stepLimit
+				continue
+			}
 			if whereRWord != "WHERE" {
-				return p.query, newError(p.i, "expected WHERE")
+				return p.query, p.trailingError(newError(p.i, "expected WHERE"))
 			}
 			p.pop()
 			p.step = stepWhereField
@@ -288,7 +697,7 @@ func (p *parser) doParse() (query.Query, error) {
 			p.step = stepInsertFields
 		case stepInsertFields:
 			identifier := p.peek(false)
-			if isId, _ := isIdentifier(identifier); !isId {
+			if isId, _ := isIdentifier(identifier); !isId && !p.quotedName {
 				return p.query, newError(p.i, "at INSERT INTO: expected at least one field to insert")
 			}
 			p.query.Fields = append(p.query.Fields, identifier)
@@ -300,6 +709,9 @@ func (p *parser) doParse() (query.Query, error) {
 				return p.query, newError(p.i, "at INSERT INTO: expected comma or closing parens")
 			}
 			p.pop()
+			if commaOrClosingParens == "," && p.opts.AllowTrailingCommas && p.peek(false) == ")" && !p.peekQuoted {
+				continue
+			}
 			if commaOrClosingParens == "," {
 				p.step = stepInsertFields
 				continue
@@ -317,12 +729,30 @@ func (p *parser) doParse() (query.Query, error) {
 			if openingParens != "(" {
 				return p.query, newError(p.i, "at INSERT INTO: expected opening parens")
 			}
-			p.query.Inserts = append(p.query.Inserts, []string{})
+			if p.query.Inserts == nil {
+				// pre-size for all rows, the count of parens outside of quoted values is an upper bound of the
+				// row count. The values are limited by the rest of the input, a value takes 3 bytes at least, e.g. '',
+				rows, n := countRows(p.sql[p.i:]), len(p.query.Fields)
+				if limit := (len(p.sql) - p.i) / 3; rows*n > limit {
+					rows = limit / n
+				}
+				p.query.Inserts = make([][]string, 0, rows)
+				p.insertValues = make([]string, rows*n)
+			}
+			var row []string
+			if n := len(p.query.Fields); len(p.insertValues) >= n {
+				row = p.insertValues[:0:n]
+				p.insertValues = p.insertValues[n:]
+			} else {
+				row = make([]string, 0, n)
+			}
+			p.query.Inserts = append(p.query.Inserts, row)
+			p.insertRowStart = p.i
 			p.pop()
 			p.step = stepInsertValues
 		case stepInsertValues:
-			quotedValue := p.peekQuotedString(false)
-			if p.len == 0 {
+			quotedValue := p.peek(false)
+			if p.len == 0 || !p.peekQuoted {
 				return p.query, newError(p.i, "at INSERT INTO: expected quoted value")
 			}
 			p.query.Inserts[len(p.query.Inserts)-1] = append(p.query.Inserts[len(p.query.Inserts)-1], quotedValue)
@@ -334,22 +764,152 @@ func (p *parser) doParse() (query.Query, error) {
 				return p.query, newError(p.i, "at INSERT INTO: expected comma or closing parens")
 			}
 			p.pop()
+			if commaOrClosingParens == "," && p.opts.AllowTrailingCommas && p.peek(false) == ")" && !p.peekQuoted {
+				continue
+			}
 			if commaOrClosingParens == "," {
 				p.step = stepInsertValues
 				continue
 			}
 			currentInsertRow := p.query.Inserts[len(p.query.Inserts)-1]
-			if len(currentInsertRow) < len(p.query.Fields) {
-				return p.query, newError(p.i, "at INSERT INTO: value count doesn't match field count")
+			if len(currentInsertRow) != len(p.query.Fields) {
+				return p.query, newErrorf(p.insertRowStart, "at INSERT INTO: row %d has %d values, expected %d",
+					len(p.query.Inserts), len(currentInsertRow), len(p.query.Fields))
 			}
 			p.step = stepInsertValuesCommaBeforeOpeningParens
 		case stepInsertValuesCommaBeforeOpeningParens:
 			commaRWord := p.peek(false)
 			if commaRWord != "," {
-				return p.query, newError(p.i, "at INSERT INTO: expected comma")
+				return p.query, p.trailingError(newError(p.i, "at INSERT INTO: expected comma"))
 			}
 			p.pop()
 			p.step = stepInsertValuesOpeningParens
+		case stepAlterTable:
+			if err := p.popOnly("at ALTER TABLE"); err != nil {
+				return p.query, err
+			}
+			tableName := p.peek(false)
+			if len(tableName) == 0 {
+				return p.query, newError(p.i, "at ALTER TABLE: expected quoted table name")
+			}
+			p.query.TableName = tableName
+			p.pop()
+			p.step = stepAlterAction
+		case stepAlterAction:
+			var action query.AlterActionType
+			switch p.peek(true) {
+			case "ADD":
+				action = query.AddColumn
+			case "DROP":
+				action = query.DropColumn
+			default:
+				return p.query, newError(p.i, "at ALTER TABLE: expected ADD or DROP")
+			}
+			p.query.AlterActions = append(p.query.AlterActions, query.AlterAction{Action: action})
+			p.pop()
+			if p.peek(true) == "COLUMN" {
+				p.pop()
+			}
+			p.step = stepAlterColumn
+		case stepAlterColumn:
+			identifier := p.peek(false)
+			if isId, _ := isIdentifier(identifier); !isId {
+				return p.query, newError(p.i, "at ALTER TABLE: expected column name")
+			}
+			currentAction := &p.query.AlterActions[len(p.query.AlterActions)-1]
+			currentAction.Column = identifier
+			p.pop()
+			if currentAction.Action == query.AddColumn {
+				p.step = stepAlterColumnType
+				continue
+			}
+			p.step = stepAlterComma
+		case stepAlterColumnType:
+			columnType := p.peek(false)
+			if isId, _ := isIdentifier(columnType); !isId {
+				return p.query, newError(p.i, "at ALTER TABLE: expected column type")
+			}
+			p.query.AlterActions[len(p.query.AlterActions)-1].ColumnType = columnType
+			p.pop()
+			p.step = stepAlterComma
+		case stepTransaction:
+			s := p.peek(true)
+			if s != "TRANSACTION" && s != "WORK" {
+				return p.query, p.trailingError(newError(p.i, "expected end of query"))
+			}
+			p.pop()
+			p.step = stepEnd
+		case stepSavepointName:
+			name := p.peek(false)
+			if isId, _ := isIdentifier(name); !isId || p.peekQuoted {
+				return p.query, newError(p.i, "at SAVEPOINT: expected savepoint name")
+			}
+			p.query.SavepointName = name
+			p.pop()
+			p.step = stepEnd
+		case stepTableName:
+			if err := p.popOnly("at TABLE"); err != nil {
+				return p.query, err
+			}
+			tableName := p.peek(false)
+			if len(tableName) == 0 {
+				return p.query, newError(p.i, "at TABLE: expected quoted table name")
+			}
+			p.query.TableName = tableName
+			p.pop()
+			p.step = stepEnd
+			if isLimitClause(p.peek(true)) {
+				p.step = stepLimit
+			}
+		case stepDescribeTable:
+			tableName := p.peek(false)
+			if len(tableName) == 0 {
+				return p.query, newError(p.i, "at DESCRIBE: expected quoted table name")
+			}
+			p.query.TableName = tableName
+			p.pop()
+			p.step = stepEnd
+		case stepLimit:
+			// LIMIT and OFFSET are accepted in any order, e.g. OFFSET 20 LIMIT 10
+			limit, offset := false, false
+			for keyword := p.peek(true); isLimitClause(keyword); keyword = p.peek(true) {
+				var err error
+				if keyword == "LIMIT" {
+					if limit {
+						return p.query, newError(p.i, "at LIMIT: duplicate LIMIT")
+					}
+					if p.query.Limit != nil {
+						return p.query, newError(p.i, "at LIMIT: limit is already set by TOP")
+					}
+					limit = true
+					p.pop()
+					p.query.Limit, p.query.LimitParam, err = p.popLimitValue("at LIMIT")
+				} else {
+					if offset {
+						return p.query, newError(p.i, "at OFFSET: duplicate OFFSET")
+					}
+					offset = true
+					p.pop()
+					p.query.Offset, p.query.OffsetParam, err = p.popLimitValue("at OFFSET")
+					if rows := p.peek(true); err == nil && !p.peekQuoted && (rows == "ROW" || rows == "ROWS") {
+						// ANSI OFFSET n ROWS, the noise word is dropped
+						p.pop()
+					}
+				}
+				if err != nil {
+					return p.query, err
+				}
+			}
+			p.step = stepEnd
+		case stepEnd:
+			return p.query, p.trailingError(newError(p.i, "expected end of query"))
+		case stepAlterComma:
+			commaRWord := p.peek(false)
+			if commaRWord != "," {
+				return p.query, p.trailingError(newError(p.i, "at ALTER TABLE: expected comma"))
+			}
+			p.pop()
+			p.step = stepAlterAction
 		}
 	}
 }
@@ -358,7 +918,10 @@ func (p *parser) parseWhere() (bool, error) {
 	for {
 		if p.i >= len(p.sql) {
 			if len(p.query.Conditions) == 0 {
-				return true, newError(p.i, "at WHERE: empty WHERE clause")
+				return true, newCauseError(p.i, ErrEmptyWhere)
+			}
+			if p.whereParens > 0 {
+				return true, newError(p.i, "at WHERE: expected closing parens")
 			}
 			// TODO detect closed
 
@@ -366,26 +929,79 @@ func (p *parser) parseWhere() (bool, error) {
 		}
 		switch p.step {
 		case stepWhereField:
-			identifier := p.peek(false)
-			if p.peekQuoted {
-				p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1: identifier, Operand1Type: query.OpQuoted})
+			not := false
+			if p.peek(true) == "NOT" {
+				// negated condition, optionally in parens
+				not = true
+				p.pop()
+				if p.peek(false) == "(" && p.peekExpression() == "" {
+					p.whereParens++
+					p.pop()
+				}
+			}
+			if p.isMatch() {
+				c, err := p.parseMatch()
+				if err != nil {
+					return false, err
+				}
+				c.Not = not
+				p.query.Conditions = append(p.query.Conditions, c)
+				p.step = stepWhereAnd
+				continue
+			}
+			if expr := p.peekExpression(); expr != "" {
+				p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1: expr, Operand1Type: query.OpExpr, Not: not})
+				p.pop()
+				p.step = stepWhereOperator
+				continue
+			}
+			if p.peek(false) == "(" {
+				// row constructor, e.g. (a, b) IN (('1', '2'))
+				tuple, err := p.parseOperandList()
+				if err != nil {
+					return false, err
+				}
+				p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1List: tuple, Operand1Type: query.OpTuple, Not: not})
+				p.step = stepWhereOperator
+				continue
+			}
+			path, err := p.peekJSONPath("at WHERE")
+			if err != nil {
+				return false, err
+			}
+			identifier := path
+			if path == "" {
+				identifier = p.peek(false)
+			}
+			if path != "" {
+				p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1: path, Operand1Type: query.OpJSONPath, Not: not})
+			} else if p.peekQuoted {
+				p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1: identifier, Operand1Type: query.OpQuoted, Not: not})
 			} else {
 				if len(identifier) == 0 {
-					return false, newError(p.i, "at WHERE: empty WHERE clause")
-				} else if isId, _ := isIdentifier(identifier); !isId {
-					if len(p.query.Conditions) == 0 {
+					return false, newCauseError(p.i, ErrEmptyWhere)
+				} else if isId, _ := isIdentifier(identifier); !isId && !p.quotedName && !p.isQualifiedField(identifier) {
+					if len(p.query.Conditions) == 0 || not {
 						return true, newError(p.i, "at WHERE: expected field")
 					}
 					// TODO detect closed
 
 					return true, nil
 				}
-				p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1: identifier, Operand1Type: query.OpField})
+				p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1: identifier, Operand1Type: identifierType(identifier), Not: not})
 			}
 			p.pop()
+			cast, err := p.popCast("at WHERE")
+			if err != nil {
+				return false, err
+			}
+			p.query.Conditions[len(p.query.Conditions)-1].Operand1Cast = cast
+			if err := p.popCollate(&p.query.Conditions[len(p.query.Conditions)-1]); err != nil {
+				return false, err
+			}
 			p.step = stepWhereOperator
 		case stepWhereOperator:
-			operatorStr := p.peek(false)
+			operatorStr := p.peek(true)
 			currentCondition := p.query.Conditions[len(p.query.Conditions)-1]
 			operator, _ := reservedWords[operatorStr]
 			switch operator {
@@ -401,36 +1017,177 @@ func (p *parser) parseWhere() (bool, error) {
 				currentCondition.Operator = query.Lte
 			case rNE:
 				currentCondition.Operator = query.Ne
+			case rIS:
+				if p.opts.Dialect != DialectPostgres {
+					return false, newError(p.i, "at WHERE: unknown operator")
+				}
+				p.pop()
+				currentCondition.Operator = query.IsDistinctFrom
+				if p.peek(true) == "NOT" {
+					currentCondition.Operator = query.IsNotDistinctFrom
+					p.pop()
+				}
+				if p.peek(true) != "DISTINCT" {
+					return false, newError(p.i, "at WHERE: expected DISTINCT")
+				}
+				p.pop()
+				if p.peek(true) != "FROM" {
+					return false, newError(p.i, "at WHERE: expected FROM")
+				}
+			case rIN:
+				currentCondition.Operator = query.In
+			case rLIKE:
+				currentCondition.Operator = query.Like
+			case rNOT:
+				p.pop()
+				switch p.peek(true) {
+				case "IN":
+					currentCondition.Operator = query.NotIn
+				case "LIKE":
+					currentCondition.Operator = query.NotLike
+				default:
+					return false, newError(p.i, "at WHERE: expected IN or LIKE after NOT")
+				}
 			default:
 				return false, newError(p.i, "at WHERE: unknown operator")
 			}
+			if currentCondition.Operand1Type == query.OpTuple && currentCondition.Operator != query.In && currentCondition.Operator != query.NotIn {
+				return false, newError(p.i, "at WHERE: expected IN after row constructor")
+			}
 			p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
 			p.pop()
 			p.step = stepWhereValue
 		case stepWhereValue:
 			currentCondition := p.query.Conditions[len(p.query.Conditions)-1]
-			identifier := p.peek(false)
-			if p.peekQuoted {
+			if isComparison(currentCondition.Operator) {
+				switch p.peek(true) {
+				case "ANY":
+					currentCondition.Quantifier = query.Any
+				case "ALL":
+					currentCondition.Quantifier = query.All
+				}
+				if currentCondition.Quantifier != query.NoQuantifier && p.isFieldName() {
+					// a field named ANY or ALL
+					currentCondition.Quantifier = query.NoQuantifier
+				}
+				if currentCondition.Quantifier != query.NoQuantifier {
+					p.pop()
+				}
+			}
+			if currentCondition.Operand1Type == query.OpTuple {
+				list, err := p.parseTupleList(len(currentCondition.Operand1List))
+				if err != nil {
+					return false, err
+				}
+				currentCondition.Operand2List = list
+				currentCondition.Operand2Type = query.OpList
+				p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
+				p.step = stepWhereAnd
+				continue
+			}
+			if currentCondition.Quantifier != query.NoQuantifier || currentCondition.Operator == query.In || currentCondition.Operator == query.NotIn {
+				list, err := p.parseOperandList()
+				if err != nil {
+					return false, err
+				}
+				currentCondition.Operand2List = list
+				currentCondition.Operand2Type = query.OpList
+				p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
+				p.step = stepWhereAnd
+				continue
+			}
+			if expr := p.peekExpression(); expr != "" {
+				currentCondition.Operand2 = expr
+				currentCondition.Operand2Type = query.OpExpr
+				p.pop()
+				p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
+				p.step = stepWhereAnd
+				continue
+			}
+			var interval, path string
+			start := p.i
+			literal, literalType, err := p.peekLiteral("at WHERE")
+			if err == nil && literal == "" {
+				interval, err = p.peekInterval("at WHERE")
+			}
+			if err == nil && literal == "" && interval == "" {
+				path, err = p.peekJSONPath("at WHERE")
+			}
+			if err != nil {
+				return false, err
+			}
+			identifier := path
+			if path == "" && interval == "" && literal == "" {
+				identifier = p.peek(false)
+			}
+			if literal != "" {
+				currentCondition.Operand2 = literal
+				currentCondition.Operand2Type = literalType
+			} else if interval != "" {
+				currentCondition.Operand2 = interval
+				currentCondition.Operand2Type = query.OpInterval
+			} else if path != "" {
+				currentCondition.Operand2 = path
+				currentCondition.Operand2Type = query.OpJSONPath
+			} else if p.peekQuoted {
 				currentCondition.Operand2 = identifier
 				currentCondition.Operand2Type = query.OpQuoted
 			} else {
-				if isIdentifier, isNumber := isIdentifier(identifier); isIdentifier {
+				if isIdentifier, isNumber := isIdentifier(identifier); isIdentifier || p.quotedName || p.isQualifiedField(identifier) {
 					currentCondition.Operand2 = identifier
-					currentCondition.Operand2Type = query.OpField
-				} else if isNumber {
+					currentCondition.Operand2Type = identifierType(identifier)
+				} else if isNumber || p.looksLikeNumber(identifier) {
+					if err := p.validateNumber(identifier); err != nil {
+						return false, err
+					}
 					currentCondition.Operand2 = identifier
 					currentCondition.Operand2Type = query.OpNumber
 				} else {
 					return false, newError(p.i, "at WHERE: expected quoted value")
 				}
 			}
-			p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
 			p.pop()
+			if currentCondition.Operand2Cast, err = p.popCast("at WHERE"); err != nil {
+				return false, err
+			}
+			if expr, err := p.popArithmetic(start, "at WHERE"); err != nil {
+				return false, err
+			} else if expr != "" {
+				currentCondition.Operand2, currentCondition.Operand2Type, currentCondition.Operand2Cast = expr, query.OpExpr, ""
+			}
+			if err := p.popCollate(&currentCondition); err != nil {
+				return false, err
+			}
+			if (currentCondition.Operator == query.Like || currentCondition.Operator == query.NotLike) && p.peek(true) == "ESCAPE" {
+				p.pop()
+				escape := p.peek(false)
+				if !p.peekQuoted || utf8.RuneCountInString(escape) != 1 {
+					return false, newError(p.i, "at WHERE: expected single quoted character after ESCAPE")
+				}
+				currentCondition.Escape = escape
+				p.pop()
+			}
+			p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
 			p.step = stepWhereAnd
 		case stepWhereAnd:
+			if p.whereParens > 0 {
+				if p.peek(false) != ")" {
+					return false, newError(p.i, "at WHERE: expected closing parens")
+				}
+				p.whereParens--
+				p.pop()
+				continue
+			}
 			andRWord := p.peek(true)
+			if isLimitClause(andRWord) && p.query.Type == query.Select {
+				p.step = stepLimit
+				return false, nil
+			}
+			if andRWord == "WHERE" && !p.peekQuoted {
+				return false, newError(p.i, "at WHERE: unexpected WHERE")
+			}
 			if andRWord != "AND" {
-				return false, newError(p.i, "expected AND")
+				return false, p.trailingError(newError(p.i, "expected AND"))
 			}
 			p.pop()
 			p.step = stepWhereField
@@ -442,9 +1199,618 @@ func (p *parser) parseWhere() (bool, error) {
 	}
 }
 
-func (p *parser) peekCurrent(upper bool) string {
-	if upper {
-		return p.sqlUpper[p.i : p.i+p.len]
+// parseOperandList parses a parenthesized list of values, e.g. the right side of IN
+func (p *parser) parseOperandList() ([]query.Operand, error) {
+	if p.peek(false) != "(" {
+		return nil, newError(p.i, "at WHERE: expected opening parens")
+	}
+	p.pop()
+	// pre-size for long generated lists, the commas before the closing parens estimate the list length
+	var list []query.Operand
+	if end := strings.IndexByte(p.sql[p.i:], ')'); end > 0 {
+		list = make([]query.Operand, 0, strings.Count(p.sql[p.i:p.i+end], ",")+1)
+	}
+	for {
+		literal, opType, err := p.peekLiteral("at WHERE")
+		if err != nil {
+			return nil, err
+		}
+		value := literal
+		if literal == "" {
+			value = p.peek(false)
+		}
+		if literal != "" {
+			list = append(list, query.Operand{Value: literal, Type: opType})
+		} else if p.peekQuoted {
+			list = append(list, query.Operand{Value: value, Type: query.OpQuoted})
+		} else if isIdentifier, isNumber := isIdentifier(value); isIdentifier {
+			list = append(list, query.Operand{Value: value, Type: identifierType(value)})
+		} else if isNumber || p.looksLikeNumber(value) {
+			if err := p.validateNumber(value); err != nil {
+				return nil, err
+			}
+			list = append(list, query.Operand{Value: value, Type: query.OpNumber})
+		} else {
+			return nil, newError(p.i, "at WHERE: expected value in list")
+		}
+		p.pop()
+		if list[len(list)-1].Cast, err = p.popCast("at WHERE"); err != nil {
+			return nil, err
+		}
+		commaOrClosingParens := p.peek(false)
+		if commaOrClosingParens != "," && commaOrClosingParens != ")" {
+			return nil, newError(p.i, "at WHERE: expected comma or closing parens")
+		}
+		p.pop()
+		if commaOrClosingParens == ")" {
+			return list, nil
+		}
+	}
+}
+
+// parseDerivedTable parses a subquery in FROM with its required alias, e.g. (SELECT a AS x FROM 'b') sub
+func (p *parser) parseDerivedTable() error {
+	start := p.i
+	end := p.subqueryEnd()
+	if end < 0 {
+		return newError(len(p.sql), "at FROM: expected closing parens after subquery")
+	}
+	sub := newParser(p.sql[start+1:end], p.opts)
+	q, err := sub.parse()
+	if errPos, ok := err.(*ErrorWithPos); ok {
+		errPos.pos += start + 1
+	}
+	if err != nil {
+		return err
+	}
+	if q.Type != query.Select {
+		return newError(start+1+sub.offset, "at FROM: expected SELECT in subquery")
+	}
+	p.query.FromQuery = &q
+	p.popWithLength(end + 1 - p.i)
+	if p.peek(true) == "AS" && !p.peekQuoted {
+		p.pop()
+	}
+	alias := p.peek(false)
+	if isId, _ := isIdentifier(alias); !isId || p.peekQuoted {
+		return newError(p.i, "at FROM: subquery requires an alias")
+	}
+	p.query.FromAlias = alias
+	p.pop()
+	return nil
+}
+
+// peekExpression peeks a parenthesized arithmetic expression at the current position, e.g. (a + b) in
+// (a + b) > '10'. It returns an empty string for a parenthesized list or value, e.g. (a, b) or (1).
+func (p *parser) peekExpression() string {
+	if p.i >= len(p.sql) || p.sql[p.i] != '(' {
+		return ""
+	}
+	end := p.subqueryEnd()
+	if end < 0 || !isArithmetic(p.sql[p.i+1:end]) {
+		return ""
+	}
+	p.peeked, p.len = p.sql[p.i:end+1], end+1-p.i
+	p.peekQuoted = false
+	return p.peeked
+}
+
+// popArithmetic pops the rest of an arithmetic expression without parens after the operand at start, e.g.
+// - INTERVAL '1 day' in now() - INTERVAL '1 day', and returns the whole expression verbatim. It returns an
+// empty string if the operand isn't followed by an arithmetic operator.
+func (p *parser) popArithmetic(start int, at string) (string, error) {
+	end := -1
+	for p.i < len(p.sql) && strings.IndexByte("+-*/", p.sql[p.i]) >= 0 {
+		p.popWithLength(1)
+		interval, err := p.peekInterval(at)
+		if err != nil {
+			return "", err
+		}
+		if interval == "" && p.peekExpression() == "" {
+			if literal, _, err := p.peekLiteral(at); err != nil {
+				return "", err
+			} else if literal == "" {
+				term := p.peek(false)
+				isId, isNumber := isIdentifier(term)
+				if p.len == 0 || !(p.peekQuoted || isId || isNumber || p.isQualifiedField(term) || p.looksLikeNumber(term)) {
+					return "", newError(p.i, at+": expected operand after arithmetic operator")
+				}
+			}
+		}
+		end = p.i + p.len
+		p.pop()
+	}
+	if end < 0 {
+		return "", nil
+	}
+	return p.sql[start:end], nil
+}
+
+// isArithmetic checks if s has an arithmetic operator outside of parens and quotes, e.g. a + b. A leading
+// sign isn't an operator, and s isn't arithmetic if it's a list, a comparison or a subquery.
+func isArithmetic(s string) bool {
+	start := skipSpaces(s, 0)
+	if len(s)-start >= 6 && strings.EqualFold(s[start:start+6], "SELECT") {
+		return false
+	}
+	depth := 0
+	found := false
+	for i := start; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' && c == '\'' {
+					// escaped symbol
+					i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',', '=', '<', '>', '!':
+			if depth == 0 {
+				return false
+			}
+		case '+', '-', '*', '/', '%':
+			if depth == 0 && i > start {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// popTableList pops a comma separated list of table names, e.g. of UPDATE ... FROM
+func (p *parser) popTableList(at string) ([]string, error) {
+	var tables []string
+	for {
+		tableName := p.peek(false)
+		if _, reserved := reservedWords[strings.ToUpper(tableName)]; len(tableName) == 0 || (reserved && !p.peekQuoted) {
+			return tables, newError(p.i, at+": expected quoted table name")
+		}
+		tables = append(tables, tableName)
+		p.pop()
+		if p.peek(false) != "," || p.peekQuoted {
+			return tables, nil
+		}
+		p.pop()
+	}
+}
+
+// subqueryEnd returns the position of the parens closing the one at the current position, -1 if there is
+// none. Parens in quoted strings and identifiers are skipped.
+func (p *parser) subqueryEnd() int {
+	return parensEnd(p.sql, p.i)
+}
+
+// countRows returns the count of opening parens outside of parens and quoted strings in s, e.g. 2 for
+// ('1', '(') , ('2')
+func countRows(s string) int {
+	rows, depth := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' && c == '\'' {
+					// escaped symbol
+					i++
+				}
+			}
+		case '(':
+			if depth == 0 {
+				rows++
+			}
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	return rows
+}
+
+// parensEnd returns the position of the parens closing the one at s[i], -1 if there is none. Parens in
+// quoted strings and identifiers are skipped.
+func parensEnd(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' && c == '\'' {
+					// escaped symbol
+					i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// matchModes are the search modifiers accepted after the search text of MATCH ... AGAINST
+var matchModes = map[string]bool{
+	"IN NATURAL LANGUAGE MODE":                      true,
+	"IN NATURAL LANGUAGE MODE WITH QUERY EXPANSION": true,
+	"IN BOOLEAN MODE":                               true,
+	"WITH QUERY EXPANSION":                          true,
+}
+
+// isMatch checks if a MATCH (...) full-text search condition starts at the current position. It's
+// recognized by the MySQL dialect only, otherwise match(a) is a function call.
+func (p *parser) isMatch() bool {
+	if p.opts.Dialect != DialectMySQL || !strings.HasPrefix(p.sqlUpper[p.i:], "MATCH") {
+		return false
+	}
+	i := skipSpaces(p.sql, p.i+len("MATCH"))
+	return i < len(p.sql) && p.sql[i] == '('
+}
+
+// parseMatch parses a MySQL full-text search condition, e.g. MATCH (a, b) AGAINST ('text' IN BOOLEAN MODE)
+func (p *parser) parseMatch() (query.Condition, error) {
+	p.popWithLength(len("MATCH"))
+	columns, err := p.parseOperandList()
+	if err != nil {
+		return query.Condition{}, err
+	}
+	for _, c := range columns {
+		if c.Type != query.OpField {
+			return query.Condition{}, newError(p.i, "at WHERE: expected columns in MATCH")
+		}
+	}
+	if p.peek(true) != "AGAINST" {
+		return query.Condition{}, newError(p.i, "at WHERE: expected AGAINST")
+	}
+	p.pop()
+	if p.peek(false) != "(" {
+		return query.Condition{}, newError(p.i, "at WHERE: expected opening parens")
+	}
+	p.pop()
+	text := p.peek(false)
+	if !p.peekQuoted {
+		return query.Condition{}, newError(p.i, "at WHERE: expected quoted search text after AGAINST")
+	}
+	p.pop()
+	var words []string
+	for p.peek(false) != ")" {
+		word := p.peek(true)
+		if word == "" || p.peekQuoted {
+			return query.Condition{}, newError(p.i, "at WHERE: expected closing parens")
+		}
+		words = append(words, word)
+		p.pop()
+	}
+	mode := strings.Join(words, " ")
+	if mode != "" && !matchModes[mode] {
+		return query.Condition{}, newErrorf(p.i, "at WHERE: unknown search modifier '%s'", mode)
+	}
+	p.pop()
+	return query.Condition{
+		Operand1List: columns,
+		Operand1Type: query.OpList,
+		Operator:     query.Match,
+		Operand2:     text,
+		Operand2Type: query.OpQuoted,
+		MatchMode:    mode,
+	}, nil
+}
+
+// parseTupleList parses a parenthesized list of row constructors with n values each,
+// e.g. the right side of (a, b) IN (('1', '2'), ('3', '4'))
+func (p *parser) parseTupleList(n int) ([]query.Operand, error) {
+	if p.peek(false) != "(" {
+		return nil, newError(p.i, "at WHERE: expected opening parens")
+	}
+	p.pop()
+	var list []query.Operand
+	for {
+		pos := p.i
+		tuple, err := p.parseOperandList()
+		if err != nil {
+			return nil, err
+		}
+		if len(tuple) != n {
+			return nil, newErrorf(pos, "at WHERE: expected row with %d values, got %d", n, len(tuple))
+		}
+		list = append(list, query.Operand{Type: query.OpTuple, Tuple: tuple})
+		commaOrClosingParens := p.peek(false)
+		if commaOrClosingParens != "," && commaOrClosingParens != ")" {
+			return nil, newError(p.i, "at WHERE: expected comma or closing parens")
+		}
+		p.pop()
+		if commaOrClosingParens == ")" {
+			return list, nil
+		}
+	}
+}
+
+// peekLiteral peeks a literal, which isn't a quoted string or number: a placeholder or a binary literal.
+// It returns an empty string if there is no such literal at the current position.
+func (p *parser) peekLiteral(at string) (string, query.OperandType, error) {
+	if placeholder := p.peekPlaceholder(); placeholder != "" {
+		return placeholder, query.OpPlaceholder, nil
+	}
+	return p.peekBinary(at)
+}
+
+// peekPlaceholder peeks a prepared statement parameter, ? or $n. It returns an empty string if there is
+// no placeholder at the current position.
+func (p *parser) peekPlaceholder() string {
+	if p.i >= len(p.sql) {
+		return ""
+	}
+	i := p.i + 1
+	switch p.sql[p.i] {
+	case '?':
+	case '$':
+		for ; i < len(p.sql) && p.sql[i] >= '0' && p.sql[i] <= '9'; i++ {
+		}
+		if i == p.i+1 {
+			return ""
+		}
+	default:
+		return ""
+	}
+	if i < len(p.sql) && (isIdentifierStart(p.sql[i]) || (p.sql[i] >= '0' && p.sql[i] <= '9')) {
+		return ""
+	}
+	p.peeked, p.len = p.sql[p.i:i], i-p.i
+	p.peekQuoted = false
+	return p.peeked
+}
+
+// peekBinary peeks a hexadecimal or bit string literal verbatim, e.g. x'1F' or b'1010'.
+// It returns an empty string if there is no such literal at the current position.
+func (p *parser) peekBinary(at string) (string, query.OperandType, error) {
+	if p.i+1 >= len(p.sql) || p.sql[p.i+1] != '\'' {
+		return "", query.OpUnknown, nil
+	}
+	var opType query.OperandType
+	var kind string
+	var valid func(c byte) bool
+	switch p.sql[p.i] {
+	case 'x', 'X':
+		opType, kind = query.OpHex, "hex"
+		valid = func(c byte) bool {
+			return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+		}
+	case 'b', 'B':
+		opType, kind = query.OpBit, "bit"
+		valid = func(c byte) bool { return c == '0' || c == '1' }
+	default:
+		return "", query.OpUnknown, nil
+	}
+	for i := p.i + 2; i < len(p.sql); i++ {
+		if p.sql[i] == '\'' {
+			p.peeked, p.len = p.sql[p.i:i+1], i+1-p.i
+			p.peekQuoted = false
+			return p.peeked, opType, nil
+		}
+		if !valid(p.sql[i]) {
+			break
+		}
+	}
+	return "", query.OpUnknown, newErrorf(p.i, "%s: invalid %s literal", at, kind)
+}
+
+// intervalUnits are the units allowed after an interval value, e.g. INTERVAL '1' DAY
+var intervalUnits = map[string]bool{
+	"YEAR": true, "MONTH": true, "WEEK": true, "DAY": true, "HOUR": true, "MINUTE": true, "SECOND": true,
+}
+
+// peekInterval peeks an interval literal verbatim, e.g. INTERVAL '1 day' or INTERVAL '2' HOUR.
+// It returns an empty string if there is no INTERVAL at the current position.
+func (p *parser) peekInterval(at string) (string, error) {
+	if p.peek(true) != "INTERVAL" || p.isFieldName() {
+		return "", nil
+	}
+	start := p.i
+	i := skipSpaces(p.sql, p.i+p.len)
+	if i >= len(p.sql) || p.sql[i] != '\'' {
+		return "", newError(i, at+": expected quoted value after INTERVAL")
+	}
+	p.i = i
+	_, n := p.peekQuotedStringWithLength(false)
+	p.i = start
+	p.peekQuoted = false
+	if n == 0 {
+		return "", newError(i, at+": expected quoted value after INTERVAL")
+	}
+	end := i + n
+	j := skipSpaces(p.sql, end)
+	k := j
+	for ; k < len(p.sql) && isIdentifierStart(p.sql[k]); k++ {
+	}
+	if intervalUnits[p.sqlUpper[j:k]] {
+		end = k
+	}
+	p.peeked, p.len = p.sql[start:end], end-start
+	return p.peeked, nil
+}
+
+// peekQuotedAlias peeks an alias quoted with single or double quotes, e.g. 'My Column', and returns
+// it unquoted. A doubled quote is unescaped.
+func (p *parser) peekQuotedAlias() (string, bool) {
+	if p.i >= len(p.sql) || (p.sql[p.i] != '\'' && p.sql[p.i] != '"') {
+		return "", false
+	}
+	quote := p.sql[p.i]
+	for i := p.i + 1; i < len(p.sql); i++ {
+		if p.sql[i] != quote {
+			continue
+		}
+		if i+1 < len(p.sql) && p.sql[i+1] == quote {
+			i++
+			continue
+		}
+		p.peeked, p.len = p.sql[p.i:i+1], i+1-p.i
+		q := string(quote)
+		return strings.ReplaceAll(p.sql[p.i+1:i], q+q, q), true
+	}
+	return "", false
+}
+
+// peekCase peeks a CASE ... END expression verbatim, e.g. CASE WHEN a > '1' THEN 'hi' ELSE 'lo' END.
+// It returns an empty string if there is no CASE at the current position.
+func (p *parser) peekCase(at string) (string, error) {
+	if p.peek(true) != "CASE" || p.isFieldName("WHEN") {
+		return "", nil
+	}
+	depth := 0
+	for i := p.i; i < len(p.sql); {
+		if p.sql[i] == '\'' {
+			// skip quoted string, a quote is escaped by a backslash or doubled
+			for i++; i < len(p.sql); i++ {
+				if p.sql[i] == '\\' {
+					i++
+				} else if p.sql[i] == '\'' {
+					if i+1 < len(p.sql) && p.sql[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			i++
+			continue
+		}
+		if !isIdentifierStart(p.sql[i]) {
+			i++
+			continue
+		}
+		start := i
+		for ; i < len(p.sql) && (isIdentifierStart(p.sql[i]) || (p.sql[i] >= '0' && p.sql[i] <= '9')); i++ {
+		}
+		switch p.sqlUpper[start:i] {
+		case "CASE":
+			depth++
+		case "END":
+			depth--
+			if depth == 0 {
+				p.peeked, p.len = p.sql[p.i:i], i-p.i
+				return p.peeked, nil
+			}
+		}
+	}
+	return "", newError(len(p.sql), at+": expected END")
+}
+
+// parseOver parses the window of a window function, e.g. OVER (PARTITION BY a ORDER BY b). The PARTITION BY
+// and ORDER BY specifications are stored verbatim, a frame clause is kept in the ORDER BY specification.
+func (p *parser) parseOver() (query.Window, error) {
+	p.pop()
+	if p.i >= len(p.sql) || p.sql[p.i] != '(' {
+		return query.Window{}, newError(p.i, "at SELECT: expected opening parens after OVER")
+	}
+	depth := 0
+	// the start of the specifications, after BY, and the start of the ORDER BY clause
+	partition, order, orderClause := -1, -1, -1
+	for i := p.i; i < len(p.sql); i++ {
+		switch c := p.sql[i]; {
+		case c == '\'':
+			// skip quoted string, a doubled quote is skipped as two adjacent quoted strings
+			for i++; i < len(p.sql) && p.sql[i] != '\''; i++ {
+				if p.sql[i] == '\\' {
+					i++
+				}
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			body := p.sql[p.i+1 : i]
+			var window query.Window
+			if order >= 0 {
+				window.OrderBy = strings.TrimSpace(body[order-p.i-1:])
+				body = body[:orderClause-p.i-1]
+			}
+			if partition >= 0 {
+				window.PartitionBy = strings.TrimSpace(body[partition-p.i-1:])
+			}
+			p.popWithLength(i + 1 - p.i)
+			return window, nil
+		case depth == 1 && isIdentifierStart(c) && (i == 0 || !isIdentifierStart(p.sql[i-1])):
+			if by := windowClause(p.sqlUpper[i:], "PARTITION"); by > 0 && partition < 0 && order < 0 {
+				partition = i + by
+				i += by - 1
+			} else if by := windowClause(p.sqlUpper[i:], "ORDER"); by > 0 && order < 0 {
+				orderClause, order = i, i+by
+				i += by - 1
+			}
+		}
+	}
+	return query.Window{}, newError(len(p.sql), "at SELECT: expected closing parens after OVER")
+}
+
+// windowClause returns the length of the clause keyword followed by BY at the start of s, e.g. ORDER BY,
+// 0 if s doesn't start with it
+func windowClause(s, keyword string) int {
+	if !strings.HasPrefix(s, keyword) {
+		return 0
+	}
+	i := skipSpaces(s, len(keyword))
+	if i == len(keyword) || !strings.HasPrefix(s[i:], "BY") || (i+2 < len(s) && isIdentifierStart(s[i+2])) {
+		return 0
+	}
+	return i + 2
+}
+
+// peekJSONPath peeks a Postgres JSON access chain, e.g. data->'a'->>'b' or data->0.
+// It returns an empty string if the dialect isn't Postgres or there is no chain at the current position.
+func (p *parser) peekJSONPath(at string) (string, error) {
+	if p.opts.Dialect != DialectPostgres || p.i >= len(p.sql) || !isIdentifierStart(p.sql[p.i]) {
+		return "", nil
+	}
+	i := p.i + 1
+	for ; i < len(p.sql) && (isIdentifierStart(p.sql[i]) || (p.sql[i] >= '0' && p.sql[i] <= '9') || p.sql[i] == '.'); i++ {
+	}
+	end := i
+	for {
+		j := skipSpaces(p.sql, end)
+		if !strings.HasPrefix(p.sql[j:], "->") {
+			break
+		}
+		j += 2
+		if j < len(p.sql) && p.sql[j] == '>' {
+			j++
+		}
+		j = skipSpaces(p.sql, j)
+		k := j
+		if k < len(p.sql) && p.sql[k] == '\'' {
+			for k++; k < len(p.sql) && (p.sql[k] != '\'' || p.sql[k-1] == '\\'); k++ {
+			}
+			if k == len(p.sql) {
+				return "", newError(j, at+": expected JSON path key")
+			}
+			k++
+		} else {
+			for ; k < len(p.sql) && p.sql[k] >= '0' && p.sql[k] <= '9'; k++ {
+			}
+			if k == j {
+				return "", newError(j, at+": expected JSON path key")
+			}
+		}
+		end = k
+	}
+	if end == i {
+		return "", nil
+	}
+	p.peeked, p.len = p.sql[p.i:end], end-p.i
+	return p.peeked, nil
+}
+
+func (p *parser) peekCurrent(upper bool) string {
+	if upper {
+		return p.sqlUpper[p.i : p.i+p.len]
 	} else {
 		return p.sql[p.i : p.i+p.len]
 	}
@@ -471,6 +1837,7 @@ func (p *parser) pop() string {
 	p.i += p.len
 	p.len = 0
 	p.peekQuoted = false
+	p.quotedName = false
 	p.popWhitespace()
 	return peeked
 }
@@ -480,8 +1847,34 @@ func (p *parser) popWithLength(len int) {
 	p.popWhitespace()
 }
 
+// popWhitespace skips whitespace, newlines included, and comments, -- to the end of line or /* */. Comments are stored in
+// the query if opts.KeepComments is set.
 func (p *parser) popWhitespace() {
-	for ; p.i < len(p.sql) && p.sql[p.i] == ' '; p.i++ {
+	for p.i < len(p.sql) {
+		var end int
+		if isSpace(p.sql[p.i]) {
+			p.i++
+			continue
+		} else if strings.HasPrefix(p.sql[p.i:], "--") {
+			if end = strings.IndexByte(p.sql[p.i:], '\n'); end < 0 {
+				end = len(p.sql) - p.i
+			}
+		} else if strings.HasPrefix(p.sql[p.i:], "/*") {
+			if end = strings.Index(p.sql[p.i+2:], "*/"); end < 0 {
+				end = len(p.sql) - p.i
+			} else {
+				end += 4
+			}
+		} else {
+			return
+		}
+		if p.opts.KeepComments {
+			p.query.Comments = append(p.query.Comments, query.Comment{
+				Text: strings.TrimRight(p.sql[p.i:p.i+end], "\r"),
+				Pos:  p.i + p.offset,
+			})
+		}
+		p.i += end
 	}
 }
 
@@ -511,6 +1904,12 @@ const (
 	rWHERE        // "WHERE"
 	rFROM         // "FROM"
 	rSET          // "SET"
+	rIS           // "IS"
+	rNOT          // "NOT"
+	rDISTINCT     // "DISTINCT"
+	rIN           // "IN"
+	rLIKE         // "LIKE"
+	rAND          // "AND"
 	r
 )
 
@@ -527,29 +1926,66 @@ var (
 	}
 
 	reservedWords = map[string]rWord{
-		"(":      rLeftBracket,
-		")":      rRightBracket,
-		">":      rGT,
-		">=":     rGTE,
-		"<":      rLT,
-		"<=":     rLTE,
-		"=":      rEQ,
-		"!=":     rNE,
-		",":      rCOMMA,
-		";":      rSEMI,
-		"AS":     rAS,
-		"SELECT": rSELECT,
-		"INSERT": rINSERT,
-		"INTO":   rINTO,
-		"VALUES": rVALUES,
-		"UPDATE": rUPDATE,
-		"DELETE": rDELETE,
-		"FROM":   rFROM,
-		"WHERE":  rWHERE,
-		"SET":    rSET,
+		"(":        rLeftBracket,
+		")":        rRightBracket,
+		">":        rGT,
+		">=":       rGTE,
+		"<":        rLT,
+		"<=":       rLTE,
+		"=":        rEQ,
+		"!=":       rNE,
+		"<>":       rNE,
+		",":        rCOMMA,
+		";":        rSEMI,
+		"AS":       rAS,
+		"SELECT":   rSELECT,
+		"INSERT":   rINSERT,
+		"INTO":     rINTO,
+		"VALUES":   rVALUES,
+		"UPDATE":   rUPDATE,
+		"DELETE":   rDELETE,
+		"FROM":     rFROM,
+		"WHERE":    rWHERE,
+		"SET":      rSET,
+		"IS":       rIS,
+		"NOT":      rNOT,
+		"DISTINCT": rDISTINCT,
+		"IN":       rIN,
+		"LIKE":     rLIKE,
+		"AND":      rAND,
 	}
 )
 
+// dialectKeywords are the keywords only recognized by a dialect, they are identifiers in other dialects
+var dialectKeywords = map[Dialect]map[string]bool{
+	DialectSQLServer: {"TOP": true, "PERCENT": true},
+	DialectMySQL:     {"MATCH": true, "AGAINST": true},
+}
+
+// IsKeyword checks if word is a keyword recognized by the parser, ignoring case. Symbols like "=" are
+// not keywords. It's backed by query.IsKeyword, the keywords in reservedWords are a subset of it.
+func IsKeyword(word string) bool {
+	return query.IsKeyword(word)
+}
+
+// IsDialectKeyword is like IsKeyword, but also checks the keywords specific to dialect, e.g. TOP for
+// DialectSQLServer.
+func IsDialectKeyword(word string, dialect Dialect) bool {
+	return IsKeyword(word) || dialectKeywords[dialect][strings.ToUpper(word)]
+}
+
+// suggestedTokens are the keywords and symbols checked by NextExpected, in sorted order
+var suggestedTokens = func() []string {
+	tokens := append([]string{"*"}, query.Keywords()...)
+	for word := range reservedWords {
+		if !isIdentifierStart(word[0]) {
+			tokens = append(tokens, word)
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}()
+
 func (p *parser) peekWithLength(upper bool) (string, int) {
 	if p.i >= len(p.sql) {
 		return "", 0
@@ -558,6 +1994,14 @@ func (p *parser) peekWithLength(upper bool) (string, int) {
 	if p.sql[p.i] == '\'' { // Quoted string
 		return p.peekQuotedStringWithLength(upper)
 	}
+	if quote := p.opts.stringQuote(); quote != 0 && p.sql[p.i] == quote {
+		return p.peekQuotedStringWithLength(upper)
+	}
+	if name, n := p.peekQuotedNameWithLength(); n > 0 {
+		p.peekQuoted, p.quotedName = false, true
+		return name, n
+	}
+	p.quotedName = false
 
 	// for _, rWord := range reservedWords {
 	// 	token := p.sqlUpper[p.i:min(len(p.sqlUpper), p.i+len(rWord))]
@@ -573,19 +2017,104 @@ func (p *parser) peekWithLength(upper bool) (string, int) {
 	return p.peekIdentifierWithLength(upper)
 }
 
+// peekQuotedNameWithLength peeks a name with double quoted parts, e.g. "My Schema".users or t."Id", if
+// double quotes delimit identifiers. The parts are returned unquoted, with a doubled quote unescaped, and
+// joined by dots, e.g. My Schema.users. The name isn't upper cased, a quoted part is case sensitive. The
+// length is 0 if there is no quoted part at the current position.
+func (p *parser) peekQuotedNameWithLength() (string, int) {
+	if !p.quotedIdents {
+		return "", 0
+	}
+	// skip the unquoted parts before the first quoted one without allocating, most names have none
+	i := p.i
+	for i < len(p.sql) && (isIdentifierStart(p.sql[i]) || (i > p.i && (p.sql[i] >= '0' && p.sql[i] <= '9' || p.sql[i] == '.'))) {
+		i++
+	}
+	if i >= len(p.sql) || p.sql[i] != '"' || (i > p.i && p.sql[i-1] != '.') {
+		return "", 0
+	}
+	var sb strings.Builder
+	sb.WriteString(p.sql[p.i:i])
+	for {
+		if p.sql[i] == '"' {
+			end := i + 1
+			for ; end < len(p.sql); end++ {
+				if p.sql[end] != '"' {
+					continue
+				}
+				if end+1 < len(p.sql) && p.sql[end+1] == '"' {
+					end++
+					continue
+				}
+				break
+			}
+			if end >= len(p.sql) || end == i+1 {
+				// unterminated or empty
+				return "", 0
+			}
+			sb.WriteString(strings.ReplaceAll(p.sql[i+1:end], `""`, `"`))
+			i = end + 1
+		} else {
+			start := i
+			for i < len(p.sql) && (isIdentifierStart(p.sql[i]) || (i > start && p.sql[i] >= '0' && p.sql[i] <= '9')) {
+				i++
+			}
+			sb.WriteString(p.sql[start:i])
+		}
+		if i+1 >= len(p.sql) || p.sql[i] != '.' || (p.sql[i+1] != '"' && !isIdentifierStart(p.sql[i+1])) {
+			return sb.String(), i - p.i
+		}
+		sb.WriteByte('.')
+		i++
+	}
+}
+
+// peekQuotedStringWithLength peeks a string quoted with the quote at the current position. The string is
+// returned unescaped, i.e. with a doubled quote or a quote escaped by a backslash as a single quote, e.g. it's
+// for 'it\'s'. The length is the one of the quoted string.
 func (p *parser) peekQuotedStringWithLength(upper bool) (string, int) {
 	p.peekQuoted = true
+	quote := p.sql[p.i]
+	escaped := false
 	for i := p.i + 1; i < len(p.sql); i++ {
-		if p.sql[i] == '\'' && p.sql[i-1] != '\\' {
+		if p.sql[i] == '\\' {
+			// escaped symbol
+			escaped = true
+			i++
+		} else if p.sql[i] == quote && i+1 < len(p.sql) && p.sql[i+1] == quote {
+			// doubled quote
+			escaped = true
+			i++
+		} else if p.sql[i] == quote {
+			s := p.sql[p.i+1 : i]
 			if upper {
-				return p.sqlUpper[p.i+1 : i], len(p.sqlUpper[p.i+1:i]) + 2 // +2 for the two quotes
+				s = p.sqlUpper[p.i+1 : i]
+			}
+			if escaped {
+				s = unescapeQuoted(s, quote)
 			}
-			return p.sql[p.i+1 : i], len(p.sql[p.i+1:i]) + 2 // +2 for the two quotes
+			return s, i + 1 - p.i
 		}
 	}
 	return "", 0
 }
 
+// unescapeQuoted returns the content s of a string quoted with quote unescaped. A doubled quote and a quote
+// or backslash escaped by a backslash are unescaped, other backslashes are kept, e.g. a\%b.
+func unescapeQuoted(s string, quote byte) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if i+1 < len(s) && ((c == '\\' && (s[i+1] == '\\' || s[i+1] == '\'' || s[i+1] == quote)) || (c == quote && s[i+1] == quote)) {
+			i++
+			c = s[i]
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
 func (p *parser) peekIdentifierWithLength(upper bool) (string, int) {
 	i := p.i
 	if _, ok := reservedSymbols[p.sqlUpper[i]]; ok {
@@ -612,10 +2141,10 @@ func (p *parser) peekIdentifierWithLength(upper bool) (string, int) {
 			p.sql[i] == '-' ||
 			p.sql[i] == '.'
 		if !isIdentifierSymbol {
-			if p.sql[i] == '(' {
-				// detect function
-				if end := strings.IndexByte(p.sql[i+1:], ')'); end >= 0 {
-					i += end + 2
+			if p.sql[i] == '(' && !query.IsKeyword(p.sqlUpper[p.i:i]) {
+				// detect function, with its arguments up to the balanced closing parens
+				if end := parensEnd(p.sql, i); end >= 0 {
+					i = end + 1
 				}
 			}
 			if upper {
@@ -630,15 +2159,169 @@ func (p *parser) peekIdentifierWithLength(upper bool) (string, int) {
 	return p.sql[p.i:], len(p.sql[p.i:])
 }
 
+// looksLikeNumber checks if a token, which isn't a valid number, is meant to be one, e.g. 1a.
+// It's only used with opts.ValidateNumbers, to report the invalid number.
+func (p *parser) looksLikeNumber(s string) bool {
+	return p.opts.ValidateNumbers && len(s) > 0 && ((s[0] >= '0' && s[0] <= '9') || s[0] == '-' || s[0] == '.')
+}
+
+// validateNumber checks that a number literal is well-formed if opts.ValidateNumbers is set
+func (p *parser) validateNumber(s string) error {
+	if !p.opts.ValidateNumbers {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return newErrorf(p.i, "at WHERE: invalid number '%s'", s)
+	}
+	return nil
+}
+
+// popInt pops a non-negative integer, e.g. the value of LIMIT. An integer larger than the int64 maximum
+// fails, it isn't truncated.
+func (p *parser) popInt(at string) (int64, error) {
+	n, err := strconv.ParseInt(p.peek(false), 10, 64)
+	if errors.Is(err, strconv.ErrRange) && n > 0 && !p.peekQuoted {
+		return 0, newError(p.i, at+": value out of range")
+	}
+	if err != nil || n < 0 || p.peekQuoted {
+		return 0, newError(p.i, at+": expected number")
+	}
+	p.pop()
+	return n, nil
+}
+
+// popCast pops a Postgres cast after an operand, e.g. ::date, and returns the type name, empty if there's
+// no cast. The type name may be qualified and followed by a modifier and array brackets, e.g.
+// pg_catalog.numeric(10, 2)[].
+func (p *parser) popCast(at string) (string, error) {
+	if p.opts.Dialect != DialectPostgres || !strings.HasPrefix(p.sql[p.i:], "::") {
+		return "", nil
+	}
+	start := skipSpaces(p.sql, p.i+2)
+	i := start
+	for i < len(p.sql) && (isIdentifierStart(p.sql[i]) || (i > start && (p.sql[i] >= '0' && p.sql[i] <= '9' || p.sql[i] == '.'))) {
+		i++
+	}
+	// a keyword isn't a type, e.g. in a:: AND b, except for interval
+	if name := p.sqlUpper[start:i]; i == start || query.IsKeyword(name) && name != "INTERVAL" {
+		return "", newError(start, at+": expected type after ::")
+	}
+	if i < len(p.sql) && p.sql[i] == '(' {
+		end := parensEnd(p.sql, i)
+		if end < 0 {
+			return "", newError(i, at+": expected closing parens after type modifier")
+		}
+		i = end + 1
+	}
+	for strings.HasPrefix(p.sql[i:], "[]") {
+		i += 2
+	}
+	typeName := p.sql[start:i]
+	p.popWithLength(i - p.i)
+	return typeName, nil
+}
+
+// popCollate pops a COLLATE clause after the last parsed operand of c, e.g. a = 'x' COLLATE utf8_bin.
+// The operand must be a string or a field. The collation name is stored as written, a double quoted name
+// with its quotes, e.g. "C".
+func (p *parser) popCollate(c *query.Condition) error {
+	if p.peek(true) != "COLLATE" || p.peekQuoted {
+		return nil
+	}
+	opType := c.Operand1Type
+	if c.Operator != query.UnknownOperator {
+		opType = c.Operand2Type
+	}
+	if opType != query.OpQuoted && opType != query.OpField && opType != query.OpPlaceholder {
+		return newError(p.i, "at WHERE: COLLATE is only supported after a string or field")
+	}
+	if c.Collation != "" {
+		return newError(p.i, "at WHERE: collation is already set")
+	}
+	p.pop()
+	name := p.peek(false)
+	if p.i < len(p.sql) && p.sql[p.i] == '"' {
+		end := strings.IndexByte(p.sql[p.i+1:], '"')
+		if end < 0 {
+			return newError(p.i, "at WHERE: expected collation name after COLLATE")
+		}
+		name, p.len = p.sql[p.i:p.i+end+2], end+2
+	} else if isId, _ := isIdentifier(name); !isId || p.peekQuoted {
+		return newError(p.i, "at WHERE: expected collation name after COLLATE")
+	}
+	c.Collation = name
+	p.pop()
+	return nil
+}
+
+// popLimitValue pops the value of LIMIT or OFFSET, a non-negative integer or a placeholder
+func (p *parser) popLimitValue(at string) (*int64, *query.Operand, error) {
+	if placeholder := p.peekPlaceholder(); placeholder != "" {
+		p.pop()
+		return nil, &query.Operand{Value: placeholder, Type: query.OpPlaceholder}, nil
+	}
+	n, err := p.popInt(at)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &n, nil, nil
+}
+
+// isLimitClause checks if keyword starts a LIMIT or OFFSET clause
+func isLimitClause(keyword string) bool {
+	return keyword == "LIMIT" || keyword == "OFFSET"
+}
+
+// popOnly pops the ONLY keyword before a table name, e.g. DELETE FROM ONLY 'a'. It's supported
+// by the Postgres dialect only, to exclude inheriting tables.
+func (p *parser) popOnly(at string) error {
+	if p.peek(true) != "ONLY" || p.peekQuoted || p.isFieldName() {
+		// a table named only, e.g. FROM only WHERE
+		return nil
+	}
+	if p.opts.Dialect != DialectPostgres {
+		return newError(p.i, at+": ONLY is only supported by the Postgres dialect")
+	}
+	p.query.OnlyTable = true
+	p.pop()
+	return nil
+}
+
+// trailingError returns err for a token after a statement that could have ended. With
+// opts.StrictTrailing it's reported as an unexpected token instead.
+func (p *parser) trailingError(err *ErrorWithPos) *ErrorWithPos {
+	if p.opts.StrictTrailing {
+		return newError(p.i, "at end: unexpected token")
+	}
+	return err
+}
+
 func (p *parser) validate() error {
 	if len(p.query.Conditions) == 0 && p.step == stepWhereField {
-		return newError(p.i, "at WHERE: empty WHERE clause")
+		return newCauseError(p.i, ErrEmptyWhere)
+	}
+	if p.query.Type == query.UnknownType && p.query.Explain {
+		return newError(p.i, "at EXPLAIN: expected statement to explain")
 	}
 	if p.query.Type == query.UnknownType {
-		return newError(p.i, "query type cannot be empty")
+		return newCauseError(p.i, ErrEmptyQuery)
+	}
+	if p.step == stepSelectFromTable {
+		return newError(p.i, "at SELECT: expected quoted table name")
+	}
+	if p.step == stepSelectField && len(p.query.Fields) > 0 {
+		return newError(p.i, "at SELECT: expected field to SELECT")
+	}
+	if p.query.Type == query.Savepoint && p.query.SavepointName == "" {
+		return newError(p.i, "at SAVEPOINT: expected savepoint name")
+	}
+	switch p.query.Type {
+	case query.Begin, query.Commit, query.Rollback, query.Savepoint:
+		// transaction control has no table
+		return nil
 	}
 	if (p.query.Type != query.Select || len(p.query.Fields) == 0) && p.query.TableName == "" {
-		return newError(p.i, "table name cannot be empty")
+		return newCauseError(p.i, ErrNoTable)
 	}
 	if len(p.query.Conditions) == 0 && (p.query.Type == query.Update || p.query.Type == query.Delete) {
 		return newError(p.i, "at WHERE: WHERE clause is mandatory for UPDATE & DELETE")
@@ -650,7 +2333,7 @@ func (p *parser) validate() error {
 		if c.Operand1 == "" && c.Operand1Type == query.OpField {
 			return newError(p.i, "at WHERE: condition with empty left side operand")
 		}
-		if c.Operand2 == "" && c.Operand2Type == query.OpField {
+		if c.Operand2 == "" && (c.Operand2Type == query.OpField || c.Operand2Type == query.OpUnknown) {
 			return newError(p.i, "at WHERE: condition with empty right side operand")
 		}
 	}
@@ -664,6 +2347,17 @@ func (p *parser) validate() error {
 			}
 		}
 	}
+	if p.query.Type == query.Alter && len(p.query.AlterActions) == 0 {
+		return newError(p.i, "at ALTER TABLE: need at least one action")
+	}
+	for _, a := range p.query.AlterActions {
+		if a.Column == "" {
+			return newError(p.i, "at ALTER TABLE: expected column name")
+		}
+		if a.Action == query.AddColumn && a.ColumnType == "" {
+			return newError(p.i, "at ALTER TABLE: expected column type")
+		}
+	}
 	if p.query.Type == query.Select && len(p.query.Fields) != len(p.query.Aliases) {
 		return newError(p.i, "fileds and aliases count mismatch")
 	}
@@ -674,10 +2368,10 @@ func isIdentifier(s string) (bool, bool) {
 	if len(s) == 0 {
 		return false, false
 	}
-	u := strings.ToUpper(s)
-
-	if _, ok := reservedWords[u]; ok {
-		return false, false
+	if u, ok := upperWord(s); ok {
+		if _, ok := reservedWords[string(u[:len(s)])]; ok {
+			return false, false
+		}
 	}
 
 	if s[0] == '-' || (s[0] >= '0' && s[0] <= '9') {
@@ -708,6 +2402,141 @@ func isIdentifier(s string) (bool, bool) {
 	return false, false
 }
 
+// isQualifiedField checks if s is a column qualified by its table, e.g. b.id, or a function call qualified by
+// its schema, e.g. public.f(a), or s is the peeked name with double quoted parts, e.g. "My Schema".users.id.
+// They're accepted in SELECT fields and conditions, e.g. to refer to a derived table, and by the Postgres
+// dialect in UPDATE values and conditions, to refer to the tables of UPDATE ... FROM.
+func (p *parser) isQualifiedField(s string) bool {
+	if (p.opts.Dialect != DialectPostgres && p.query.Type != query.Select) || p.peekQuoted {
+		return false
+	}
+	if p.quotedName {
+		return true
+	}
+	if parens := strings.IndexByte(s, '('); parens >= 0 {
+		// the arguments are kept verbatim, like for an unqualified function
+		if s[len(s)-1] != ')' {
+			return false
+		}
+		s = s[:parens]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if isId, _ := isIdentifier(part); !isId {
+			return false
+		}
+	}
+	return true
+}
+
+// isComparison checks if op is a comparison operator, which can be used with ANY or ALL
+func isComparison(op query.Operator) bool {
+	switch op {
+	case query.Eq, query.Ne, query.Gt, query.Lt, query.Gte, query.Lte:
+		return true
+	default:
+		return false
+	}
+}
+
+// niladicFunctions are the functions called without parentheses, e.g. WHERE a < CURRENT_TIMESTAMP
+var niladicFunctions = map[string]bool{
+	"CURRENT_TIMESTAMP": true,
+	"CURRENT_DATE":      true,
+	"CURRENT_TIME":      true,
+}
+
+// identifierType returns the operand type for a string accepted by isIdentifier: OpFunc for a function call
+// like lower(a) or a niladic function like CURRENT_DATE, OpField otherwise
+func identifierType(s string) query.OperandType {
+	if s[len(s)-1] == ')' {
+		return query.OpFunc
+	}
+	if u, ok := upperWord(s); ok && niladicFunctions[string(u[:len(s)])] {
+		return query.OpFunc
+	}
+	return query.OpField
+}
+
+// upperWord returns s upper cased in a fixed size buffer, so that looking it up in a map of keywords doesn't
+// allocate for every field and operand. Only ASCII letters are changed, which is enough as the keywords are
+// ASCII and identifiers are too. ok is false if s is longer than any keyword.
+func upperWord(s string) (u [32]byte, ok bool) {
+	if len(s) > len(u) {
+		return u, false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		u[i] = c
+	}
+	return u, true
+}
+
+// isFieldName checks if the peeked keyword, which is matched only in its own step, is a field of that name
+// instead, i.e. it's followed by the end of the query, a symbol ending an operand or a keyword other than the
+// given ones, e.g. ANY in a = any AND b = '1'
+func (p *parser) isFieldName(keywords ...string) bool {
+	i := skipSpaces(p.sql, p.i+p.len)
+	if i >= len(p.sql) {
+		return true
+	}
+	switch p.sql[i] {
+	case ')', ',', ';', ':', '+', '-', '*', '/', '=', '<', '>', '!':
+		return true
+	}
+	end := i
+	for end < len(p.sql) && isIdentifierStart(p.sql[end]) {
+		end++
+	}
+	word := p.sqlUpper[i:end]
+	for _, keyword := range keywords {
+		if word == keyword {
+			return false
+		}
+	}
+	return query.IsKeyword(word)
+}
+
+func isIdentifierStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+// skipSpaces returns the position of the first symbol of s at or after i which isn't whitespace or inside a
+// comment, so a comment separates tokens like a space, e.g. INTERVAL/* c */'1'
+func skipSpaces(s string, i int) int {
+	for i < len(s) {
+		switch {
+		case isSpace(s[i]):
+			i++
+		case strings.HasPrefix(s[i:], "--"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 1
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
 func isIdentifierOrAsterisk(s string) (bool, bool) {
 	if s == "*" {
 		return true, false
@@ -1,13 +1,19 @@
 package sqlparser
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/msaf1980/sqlparser/query"
 	"github.com/stretchr/testify/require"
@@ -19,6 +25,7 @@ type testCase struct {
 	Expected query.Query
 	Err      error
 	Ended    bool
+	Options  Options
 }
 
 type output struct {
@@ -54,6 +61,18 @@ func TestSQL(t *testing.T) {
 			Expected: query.Query{Type: query.Select},
 			Err:      fmt.Errorf("at SELECT: expected field to SELECT"),
 		},
+		{
+			Name:     "SELECT with FROM without table fails",
+			SQL:      "SELECT a FROM",
+			Expected: query.Query{Type: query.Select, Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at SELECT: expected quoted table name"),
+		},
+		{
+			Name:     "SELECT with WHERE with incomplete condition fails",
+			SQL:      "SELECT a FROM 'b' WHERE a >",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}, Conditions: []query.Condition{{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gt}}},
+			Err:      fmt.Errorf("at WHERE: condition with empty right side operand"),
+		},
 		{
 			Name:     "SELECT with incomplete alias fails",
 			SQL:      "SELECT a AS",
@@ -84,12 +103,43 @@ func TestSQL(t *testing.T) {
 			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"version(a)"}, Aliases: []string{"version"}},
 			Err:      nil,
 		},
+		{
+			Name:     "SELECT aggregate with DISTINCT and ALL arguments works",
+			SQL:      "SELECT count(DISTINCT a), count(ALL b) AS n, count(*) FROM 'c'",
+			Expected: query.Query{Type: query.Select, TableName: "c", Fields: []string{"count(DISTINCT a)", "count(ALL b)", "count(*)"}, Aliases: []string{"", "n", ""}},
+			Err:      nil,
+		},
+		{
+			Name: "SELECT with nested function arguments works",
+			SQL:  "SELECT coalesce(nullif(a, ''), ')') AS v, count(DISTINCT (b)) FROM 'c' WHERE lower(trim(a)) = coalesce(d, 'x')",
+			Expected: query.Query{
+				Type: query.Select, TableName: "c",
+				Fields:  []string{"coalesce(nullif(a, ''), ')')", "count(DISTINCT (b))"},
+				Aliases: []string{"v", ""},
+				Conditions: []query.Condition{
+					{Operand1: "lower(trim(a))", Operand1Type: query.OpFunc, Operator: query.Eq, Operand2: "coalesce(d, 'x')", Operand2Type: query.OpFunc},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with unbalanced function arguments fails",
+			SQL:      "SELECT coalesce(nullif(a, '') FROM 'b'",
+			Expected: query.Query{Type: query.Select},
+			Err:      fmt.Errorf("at SELECT: unbalanced parentheses in expression"),
+		},
 		{
 			Name:     "SELECT works with lowercase",
 			SQL:      "select a fRoM 'b'",
 			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
 			Err:      nil,
 		},
+		{
+			Name:     "SELECT with leading BOM and trailing newlines works",
+			SQL:      "\ufeffSELECT a FROM b\n\n",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      nil,
+		},
 		{
 			Name:     "SELECT many fields works",
 			SQL:      "SELECT a, c, d FROM 'b'",
@@ -199,6 +249,58 @@ func TestSQL(t *testing.T) {
 			},
 			Err: nil,
 		},
+		{
+			Name: "SELECT with WHERE with <> and no spaces works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a<>'1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"}, Aliases: []string{"", "", ""},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Ne, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with NOT IN and NOT LIKE works",
+			SQL:  "SELECT a FROM 'b' WHERE a NOT IN ('1', '2') AND NOT (c LIKE 'x%')",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Conditions: []query.Condition{
+					{
+						Operand1: "a", Operand1Type: query.OpField, Operator: query.NotIn, Operand2Type: query.OpList,
+						Operand2List: []query.Operand{{Value: "1", Type: query.OpQuoted}, {Value: "2", Type: query.OpQuoted}},
+					},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Like, Operand2: "x%", Operand2Type: query.OpQuoted, Not: true},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with JSON path field and WHERE works (Postgres)",
+			SQL:  "SELECT data->>'name' AS name FROM 'b' WHERE data -> 'a' ->> 'b' = '1' AND c = data->0",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"data->>'name'"}, Aliases: []string{"name"},
+				Conditions: []query.Condition{
+					{Operand1: "data -> 'a' ->> 'b'", Operand1Type: query.OpJSONPath, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "data->0", Operand2Type: query.OpJSONPath},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name:     "SELECT with malformed JSON path fails (Postgres)",
+			SQL:      "SELECT a FROM 'b' WHERE data-> = '1'",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at WHERE: expected JSON path key"),
+			Options:  Options{Dialect: DialectPostgres},
+		},
 		{
 			Name: "SELECT * works",
 			SQL:  "SELECT * FROM 'b'",
@@ -223,355 +325,1857 @@ func TestSQL(t *testing.T) {
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with two conditions using AND works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1' AND b = '2'",
+			Name:     "SELECT with trailing token fails",
+			SQL:      "SELECT a FROM 'b' garbage",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("expected WHERE"),
+		},
+		{
+			Name:     "SELECT with trailing token fails (strict)",
+			SQL:      "SELECT a FROM 'b' garbage",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at end: unexpected token"),
+			Options:  Options{StrictTrailing: true},
+		},
+		{
+			Name: "SELECT with WHERE and trailing token fails (strict)",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' garbage",
 			Expected: query.Query{
 				Type:      query.Select,
 				TableName: "b",
-				Fields:    []string{"a", "c", "d"}, Aliases: []string{"", "", ""},
+				Fields:    []string{"a"}, Aliases: []string{""},
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Ne, Operand2: "1", Operand2Type: query.OpQuoted},
-					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpQuoted},
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
 				},
 			},
-			Err: nil,
-		},
-		{
-			Name:     "Empty UPDATE fails",
-			SQL:      "UPDATE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("table name cannot be empty"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name fails",
-			SQL:      "UPDATE 'a'",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name and SET fails",
-			SQL:      "UPDATE 'a' SET",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name, SET with a field but no value and WHERE fails",
-			SQL:      "UPDATE 'a' SET b WHERE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at UPDATE: expected '='"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name, SET with a field and = but no value and WHERE fails",
-			SQL:      "UPDATE 'a' SET b = WHERE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+			Err:     fmt.Errorf("at end: unexpected token"),
+			Options: Options{StrictTrailing: true},
 		},
 		{
-			Name:     "Incomplete UPDATE due to no WHERE clause fails",
-			SQL:      "UPDATE 'a' SET b = 'hello' WHERE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+			Name:     "UPDATE with trailing token fails (strict)",
+			SQL:      "UPDATE 'a' SET b = '1' garbage",
+			Expected: query.Query{Type: query.Update, TableName: "a", Updates: map[string]string{"b": "1"}},
+			Err:      fmt.Errorf("at end: unexpected token"),
+			Options:  Options{StrictTrailing: true},
 		},
 		{
-			Name:     "Incomplete UPDATE due incomplete WHERE clause fails",
-			SQL:      "UPDATE 'a' SET b = 'hello' WHERE a",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: condition without operator"),
+			Name: "SELECT with LIMIT works",
+			SQL:  "SELECT a FROM 'b' LIMIT 10",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Limit: int64Ptr(10),
+			},
+			Err: nil,
 		},
 		{
-			Name: "UPDATE works",
-			SQL:  "UPDATE 'a' SET b = 'hello' WHERE a = '1'",
+			Name: "SELECT with WHERE, LIMIT and OFFSET works",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' LIMIT 10 OFFSET 20",
 			Expected: query.Query{
-				Type:      query.Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello"},
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
 				Conditions: []query.Condition{
 					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
 				},
+				Limit:  int64Ptr(10),
+				Offset: int64Ptr(20),
 			},
 			Err: nil,
 		},
 		{
-			Name: "UPDATE works with simple quote inside",
-			SQL:  "UPDATE 'a' SET b = 'hello\\'world' WHERE a = '1'",
+			Name: "SELECT with OFFSET before LIMIT works",
+			SQL:  "SELECT a FROM 'b' OFFSET 20 LIMIT 10",
 			Expected: query.Query{
-				Type:      query.Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello\\'world"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
-				},
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Limit:  int64Ptr(10),
+				Offset: int64Ptr(20),
 			},
 			Err: nil,
 		},
 		{
-			Name: "UPDATE with multiple SETs works",
-			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1'",
+			Name: "SELECT with OFFSET only works",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' OFFSET 20",
 			Expected: query.Query{
-				Type:      query.Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello", "c": "bye"},
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
 				Conditions: []query.Condition{
 					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
 				},
+				Offset: int64Ptr(20),
 			},
 			Err: nil,
 		},
 		{
-			Name: "UPDATE with multiple SETs and multiple conditions works",
-			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1' AND b = '789'",
+			Name: "SELECT with OFFSET ROWS works",
+			SQL:  "SELECT a FROM 'b' OFFSET 5 ROWS LIMIT 10",
 			Expected: query.Query{
-				Type:      query.Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello", "c": "bye"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
-					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "789", Operand2Type: query.OpQuoted},
-				},
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Limit:  int64Ptr(10),
+				Offset: int64Ptr(5),
 			},
 			Err: nil,
 		},
 		{
-			Name:     "Empty DELETE fails",
-			SQL:      "DELETE FROM",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("table name cannot be empty"),
+			Name: "SELECT with OFFSET ROW works",
+			SQL:  "SELECT a FROM 'b' LIMIT ? offset ? row",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				LimitParam:  &query.Operand{Value: "?", Type: query.OpPlaceholder},
+				OffsetParam: &query.Operand{Value: "?", Type: query.OpPlaceholder},
+			},
+			Err: nil,
 		},
 		{
-			Name:     "DELETE without WHERE fails",
-			SQL:      "DELETE FROM 'a'",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+			Name:     "SELECT with quoted ROWS after OFFSET fails",
+			SQL:      "SELECT a FROM 'b' OFFSET 5 'ROWS'",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}, Offset: int64Ptr(5)},
+			Err:      fmt.Errorf("expected end of query"),
 		},
 		{
-			Name:     "DELETE with empty WHERE fails",
-			SQL:      "DELETE FROM 'a' WHERE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+			Name:     "DELETE with a second WHERE fails",
+			SQL:      "DELETE FROM 'a' WHERE b = '1' WHERE c = '2'",
+			Expected: query.Query{Type: query.Delete, TableName: "a"},
+			Err:      fmt.Errorf("at WHERE: unexpected WHERE"),
+			Options:  Options{StrictTrailing: true},
 		},
 		{
-			Name:     "DELETE with WHERE with field but no operator fails",
-			SQL:      "DELETE FROM 'a' WHERE b",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: condition without operator"),
+			Name:     "SELECT with trailing comma works with AllowTrailingCommas",
+			SQL:      "SELECT a, b, FROM 'c'",
+			Expected: query.Query{Type: query.Select, TableName: "c", Fields: []string{"a", "b"}, Aliases: []string{"", ""}},
+			Err:      nil,
+			Options:  Options{AllowTrailingCommas: true},
 		},
 		{
-			Name: "DELETE with WHERE works",
-			SQL:  "DELETE FROM 'a' WHERE b = '1'",
+			Name:     "SELECT with trailing comma fails",
+			SQL:      "SELECT a, b, FROM 'c'",
+			Expected: query.Query{Type: query.Select, Fields: []string{"a", "b"}, Aliases: []string{"", ""}},
+			Err:      fmt.Errorf("at SELECT: expected field to SELECT"),
+		},
+		{
+			Name:     "SELECT with only a comma fails with AllowTrailingCommas",
+			SQL:      "SELECT , FROM 'c'",
+			Expected: query.Query{Type: query.Select},
+			Err:      fmt.Errorf("at SELECT: expected field to SELECT"),
+			Options:  Options{AllowTrailingCommas: true},
+		},
+		{
+			Name:     "INSERT with trailing commas works with AllowTrailingCommas",
+			SQL:      "INSERT INTO 'a' (b, c,) VALUES ('1', '2',), ('3', '4' , )",
+			Expected: query.Query{Type: query.Insert, TableName: "a", Fields: []string{"b", "c"}, Inserts: [][]string{{"1", "2"}, {"3", "4"}}},
+			Err:      nil,
+			Options:  Options{AllowTrailingCommas: true},
+		},
+		{
+			Name:     "INSERT with trailing comma in VALUES fails",
+			SQL:      "INSERT INTO 'a' (b, c) VALUES ('1', '2',)",
+			Expected: query.Query{Type: query.Insert, TableName: "a", Fields: []string{"b", "c"}, Inserts: [][]string{{"1", "2"}}},
+			Err:      fmt.Errorf("at INSERT INTO: expected quoted value"),
+		},
+		{
+			Name:     "SELECT with duplicate LIMIT fails",
+			SQL:      "SELECT a FROM 'b' LIMIT 10 OFFSET 20 LIMIT 5",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at LIMIT: duplicate LIMIT"),
+		},
+		{
+			Name:     "SELECT with duplicate OFFSET fails",
+			SQL:      "SELECT a FROM 'b' OFFSET 20 OFFSET 5",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at OFFSET: duplicate OFFSET"),
+		},
+		{
+			Name: "SELECT with COLLATE works",
+			SQL:  "SELECT a FROM 'b' WHERE name = 'x' COLLATE utf8_bin AND c COLLATE \"C\" LIKE 'y%'",
 			Expected: query.Query{
-				Type:      query.Delete,
-				TableName: "a",
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
 				Conditions: []query.Condition{
-					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "name", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "x", Operand2Type: query.OpQuoted, Collation: "utf8_bin"},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Like, Operand2: "y%", Operand2Type: query.OpQuoted, Collation: `"C"`},
 				},
 			},
 			Err: nil,
 		},
 		{
-			Name:     "Empty INSERT fails",
-			SQL:      "INSERT INTO",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("table name cannot be empty"),
+			Name:     "SELECT with COLLATE after number fails",
+			SQL:      "SELECT a FROM 'b' WHERE c = 1 COLLATE utf8_bin",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at WHERE: COLLATE is only supported after a string or field"),
 		},
 		{
-			Name:     "INSERT with no rows to insert fails",
-			SQL:      "INSERT INTO 'a'",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Name:     "SELECT with COLLATE on both operands fails",
+			SQL:      "SELECT a FROM 'b' WHERE c COLLATE x = 'd' COLLATE y",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at WHERE: collation is already set"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails",
-			SQL:      "INSERT INTO 'a' (",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Name: "SELECT with placeholders works",
+			SQL:  "SELECT a FROM 'b' WHERE a = ? AND c IN ($1, '2') LIMIT ? OFFSET $2",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "?", Operand2Type: query.OpPlaceholder},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.In, Operand2Type: query.OpList, Operand2List: []query.Operand{{Value: "$1", Type: query.OpPlaceholder}, {Value: "2", Type: query.OpQuoted}}},
+				},
+				LimitParam:  &query.Operand{Value: "?", Type: query.OpPlaceholder},
+				OffsetParam: &query.Operand{Value: "$2", Type: query.OpPlaceholder},
+			},
+			Err: nil,
 		},
 		{
-			Name:     "INSERT with incomplete value section fails #2",
-			SQL:      "INSERT INTO 'a' (b",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Name:     "SELECT with LIMIT without number fails",
+			SQL:      "SELECT a FROM 'b' LIMIT a",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at LIMIT: expected number"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails #3",
-			SQL:      "INSERT INTO 'a' (b)",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Name:     "SELECT with oversized LIMIT fails",
+			SQL:      "SELECT a FROM 'b' LIMIT 99999999999999999999999",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at LIMIT: value out of range"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails #4",
-			SQL:      "INSERT INTO 'a' (b) VALUES",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Name:     "SELECT with oversized OFFSET fails",
+			SQL:      "SELECT a FROM 'b' LIMIT 9223372036854775807 OFFSET 9223372036854775808",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}, Limit: int64Ptr(9223372036854775807)},
+			Err:      fmt.Errorf("at OFFSET: value out of range"),
 		},
 		{
-			Name:     "INSERT with incomplete row fails",
-			SQL:      "INSERT INTO 'a' (b) VALUES (",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: value count doesn't match field count"),
+			Name:     "DELETE with LIMIT fails",
+			SQL:      "DELETE FROM 'a' WHERE b = '1' LIMIT 1",
+			Expected: query.Query{Type: query.Delete, TableName: "a", Conditions: []query.Condition{{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted}}},
+			Err:      fmt.Errorf("expected AND"),
 		},
 		{
-			Name: "INSERT works",
-			SQL:  "INSERT INTO 'a' (b) VALUES ('1')",
+			Name: "SELECT DISTINCT works",
+			SQL:  "SELECT DISTINCT a FROM 'b'",
 			Expected: query.Query{
-				Type:      query.Insert,
-				TableName: "a",
-				Fields:    []string{"b"},
-				Inserts:   [][]string{{"1"}},
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Distinct: true,
 			},
 			Err: nil,
 		},
 		{
-			Name: "INSERT * fails",
-			SQL:  "INSERT INTO 'a' (*) VALUES ('1')",
+			Name: "SELECT DISTINCT TOP works (SQL Server)",
+			SQL:  "SELECT DISTINCT TOP 10 a FROM 'b'",
 			Expected: query.Query{
-				Type:      query.Insert,
-				TableName: "a",
-				Fields:    []string{"*"},
-				Inserts:   [][]string{{"1"}},
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Distinct: true,
+				Limit:    int64Ptr(10),
 			},
-			Err: fmt.Errorf("at INSERT INTO: expected at least one field to insert"),
+			Err:     nil,
+			Options: Options{Dialect: DialectSQLServer},
 		},
 		{
-			Name: "INSERT with multiple fields works",
-			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' )",
+			Name: "SELECT TOP PERCENT works (SQL Server)",
+			SQL:  "SELECT TOP (5) PERCENT a FROM 'b'",
 			Expected: query.Query{
-				Type:      query.Insert,
-				TableName: "a",
-				Fields:    []string{"b", "c", "d"},
-				Inserts:   [][]string{{"1", "2", "3"}},
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Limit:        int64Ptr(5),
+				LimitPercent: true,
 			},
-			Err: nil,
+			Err:     nil,
+			Options: Options{Dialect: DialectSQLServer},
 		},
 		{
-			Name: "INSERT with multiple fields and multiple values works",
-			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' ),('4','5' ,'6' )",
+			Name:     "SELECT TOP without number fails (SQL Server)",
+			SQL:      "SELECT TOP a FROM 'b'",
+			Expected: query.Query{Type: query.Select},
+			Err:      fmt.Errorf("at SELECT: expected number after TOP"),
+			Options:  Options{Dialect: DialectSQLServer},
+		},
+		{
+			Name:     "SELECT TOP with quoted number fails (SQL Server)",
+			SQL:      "SELECT TOP '10' a FROM 'b'",
+			Expected: query.Query{Type: query.Select},
+			Err:      fmt.Errorf("at SELECT: expected number after TOP"),
+			Options:  Options{Dialect: DialectSQLServer},
+		},
+		{
+			Name:     "SELECT TOP fails",
+			SQL:      "SELECT TOP 10 a FROM 'b'",
+			Expected: query.Query{Type: query.Select, Fields: []string{"TOP"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at SELECT: expected comma or FROM"),
+		},
+		{
+			Name: "SELECT with quoted aliases works",
+			SQL:  "SELECT a AS 'My Column', b AS \"it's \"\"b\"\"\", c AS 'it''s' FROM 'd'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "d",
+				Fields:    []string{"a", "b", "c"},
+				Aliases:   []string{"My Column", "it's \"b\"", "it's"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with CASE works",
+			SQL:  "SELECT a, CASE WHEN b > '1' THEN 'it''s end' ELSE CASE c WHEN 1 THEN 'x' END END AS label FROM 'd'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "d",
+				Fields:    []string{"a", "CASE WHEN b > '1' THEN 'it''s end' ELSE CASE c WHEN 1 THEN 'x' END END"},
+				Aliases:   []string{"", "label"},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with CASE without END fails",
+			SQL:      "SELECT CASE WHEN b > '1' THEN 'hi' FROM 'd'",
+			Expected: query.Query{Type: query.Select},
+			Err:      fmt.Errorf("at SELECT: expected END"),
+		},
+		{
+			Name: "DELETE FROM ONLY works (Postgres)",
+			SQL:  "DELETE FROM ONLY 'a' WHERE b = '1'",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "a",
+				OnlyTable: true,
+				Conditions: []query.Condition{
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "SELECT FROM ONLY works (Postgres)",
+			SQL:  "SELECT a FROM only 'b'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				OnlyTable: true,
+				Fields:    []string{"a"}, Aliases: []string{""},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "SELECT with double quoted strings works (StringQuote)",
+			SQL:  `SELECT a FROM "b" WHERE c = "say ""hello""" AND d = 'x'`,
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: `say "hello"`, Operand2Type: query.OpQuoted},
+					{Operand1: "d", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "x", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:     nil,
+			Options: Options{StringQuote: '"'},
+		},
+		{
+			Name: "INSERT with backtick quoted strings works (StringQuote)",
+			SQL:  "INSERT INTO 'a' (b) VALUES (`c`)",
 			Expected: query.Query{
 				Type:      query.Insert,
 				TableName: "a",
-				Fields:    []string{"b", "c", "d"},
-				Inserts:   [][]string{{"1", "2", "3"}, {"4", "5", "6"}},
+				Fields:    []string{"b"},
+				Inserts:   [][]string{{"c"}},
+			},
+			Err:     nil,
+			Options: Options{StringQuote: '`'},
+		},
+		{
+			Name:     "SELECT with unsupported string quote fails",
+			SQL:      "SELECT a FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("unsupported string quote '#'"),
+			Options:  Options{StringQuote: '#'},
+		},
+		{
+			Name: "UPDATE FROM works (Postgres)",
+			SQL:  "UPDATE 'a' SET x = b.y, z = '1' FROM 'b', 'c' WHERE a.id = b.id AND c.id = 2",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"x": "b.y", "z": "1"},
+				UpdateTypes: map[string]query.OperandType{"x": query.OpField},
+				UpdateFrom:  []string{"b", "c"},
+				Conditions: []query.Condition{
+					{Operand1: "a.id", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "b.id", Operand2Type: query.OpField},
+					{Operand1: "c.id", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name:     "UPDATE FROM fails",
+			SQL:      "UPDATE 'a' SET x = '1' FROM 'b' WHERE a.id = b.id",
+			Expected: query.Query{Type: query.Update, TableName: "a", Updates: map[string]string{"x": "1"}},
+			Err:      fmt.Errorf("at UPDATE: FROM is only supported by the Postgres dialect"),
+		},
+		{
+			Name:     "UPDATE FROM without table fails",
+			SQL:      "UPDATE 'a' SET x = '1' FROM WHERE a.id = b.id",
+			Expected: query.Query{Type: query.Update, TableName: "a", Updates: map[string]string{"x": "1"}},
+			Err:      fmt.Errorf("at UPDATE FROM: expected quoted table name"),
+			Options:  Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "DELETE USING works (Postgres)",
+			SQL:  "DELETE FROM 'a' USING 'b', c WHERE a.id = b.id AND c.x = '1'",
+			Expected: query.Query{
+				Type:        query.Delete,
+				TableName:   "a",
+				DeleteUsing: []string{"b", "c"},
+				Conditions: []query.Condition{
+					{Operand1: "a.id", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "b.id", Operand2Type: query.OpField},
+					{Operand1: "c.x", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name:     "DELETE USING fails",
+			SQL:      "DELETE FROM 'a' USING 'b' WHERE a.id = b.id",
+			Expected: query.Query{Type: query.Delete, TableName: "a"},
+			Err:      fmt.Errorf("at DELETE FROM: USING is only supported by the Postgres dialect"),
+		},
+		{
+			Name:     "DELETE USING without table fails",
+			SQL:      "DELETE FROM 'a' USING WHERE a.id = b.id",
+			Expected: query.Query{Type: query.Delete, TableName: "a"},
+			Err:      fmt.Errorf("at DELETE USING: expected quoted table name"),
+			Options:  Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "qualified names with quoted parts work (Postgres)",
+			SQL:  `SELECT "My Schema".users."Id", s."t".b FROM "My Schema".users WHERE "My Schema"."users".name = u."x""y" AND s.t."c" IN ('1')`,
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "My Schema.users",
+				Fields:    []string{"My Schema.users.Id", "s.t.b"},
+				Aliases:   []string{"", ""},
+				Conditions: []query.Condition{
+					{Operand1: "My Schema.users.name", Operand1Type: query.OpField, Operator: query.Eq, Operand2: `u.x"y`, Operand2Type: query.OpField},
+					{Operand1: "s.t.c", Operand1Type: query.OpField, Operator: query.In, Operand2Type: query.OpList, Operand2List: []query.Operand{{Value: "1", Type: query.OpQuoted}}},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "UPDATE with qualified names with quoted parts works (Postgres)",
+			SQL:  `UPDATE "s".t SET "b" = "s".t.c WHERE a = 1`,
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "s.t",
+				Updates:     map[string]string{"b": "s.t.c"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpField},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name:     "qualified name with double quoted strings fails (MySQL)",
+			SQL:      `SELECT "s".t.c FROM 'b'`,
+			Expected: query.Query{Type: query.Select, Fields: []string{"s"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at SELECT: expected comma or FROM"),
+			Options:  Options{Dialect: DialectMySQL},
+		},
+		{
+			Name: "casts work (Postgres)",
+			SQL:  "SELECT a FROM 'b' WHERE a::int = '1'::int AND c = '2021-01-01'::date AND d IN ('1'::int, $1::numeric(10, 2)[])",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Aliases:   []string{""},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operand1Cast: "int", Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted, Operand2Cast: "int"},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "2021-01-01", Operand2Type: query.OpQuoted, Operand2Cast: "date"},
+					{Operand1: "d", Operand1Type: query.OpField, Operator: query.In, Operand2Type: query.OpList, Operand2List: []query.Operand{
+						{Value: "1", Type: query.OpQuoted, Cast: "int"},
+						{Value: "$1", Type: query.OpPlaceholder, Cast: "numeric(10, 2)[]"},
+					}},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "UPDATE value cast works (Postgres)",
+			SQL:  "UPDATE 'a' SET b = '1'::int, c = '2' WHERE d = 1",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "1", "c": "2"},
+				UpdateCasts: map[string]string{"b": "int"},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "cast without type fails",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1':: AND c = 1",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq},
+				},
+			},
+			Err:     fmt.Errorf("at WHERE: expected type after ::"),
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "cast fails",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1'::int",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: fmt.Errorf("expected AND"),
+		},
+		{
+			Name:     "UPDATE with qualified value fails",
+			SQL:      "UPDATE 'a' SET x = b.y WHERE a = '1'",
+			Expected: query.Query{Type: query.Update, TableName: "a", Updates: map[string]string{}},
+			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+		},
+		{
+			Name: "SELECT schema qualified function works",
+			SQL:  "SELECT public.my_func(a), public.col FROM 't' WHERE public.lower(b) = c.d",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "t",
+				Fields:    []string{"public.my_func(a)", "public.col"}, Aliases: []string{"", ""},
+				Conditions: []query.Condition{
+					{Operand1: "public.lower(b)", Operand1Type: query.OpFunc, Operator: query.Eq, Operand2: "c.d", Operand2Type: query.OpField},
+				},
 			},
 			Err: nil,
 		},
-	}
-
-	output := output{Types: query.TypeString, Operators: query.OperatorString}
-	for _, tc := range ts {
-		t.Run(tc.Name, func(t *testing.T) {
-			actual, err := ParseMany([]string{tc.SQL})
-			if err != nil {
-				if errPos, ok := err.(*ErrorWithPos); ok {
-					fmt.Fprintln(os.Stderr, "")
-					errPos.PrintPosError(tc.SQL, os.Stderr)
-				}
-			}
-			if tc.Err != nil && err == nil {
-				t.Errorf("Error should have been %v", tc.Err)
-			}
-			if tc.Err == nil && err != nil {
-				t.Errorf("Error should have been nil but was %v", err)
-			}
-			if tc.Err != nil && err != nil {
-				require.Equal(t, tc.Err.Error(), err.Error(), "Unexpected error")
-			}
-			if len(actual) > 0 {
-				require.Equal(t, tc.Expected, actual[0], "Query didn't match expectation")
-			}
-			if tc.Err != nil {
-				output.ErrorExamples = append(output.ErrorExamples, tc)
-			} else {
-				output.NoErrorExamples = append(output.NoErrorExamples, tc)
-			}
-		})
-	}
-	createReadme(output)
-}
-
-func TestWhere(t *testing.T) {
-	ts := []testCase{
 		{
-			Name:     "empty query fails",
-			SQL:      "",
+			Name: "UPDATE with schema qualified function works (Postgres)",
+			SQL:  "UPDATE 'a' SET x = public.f(b) WHERE c = public.g()",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"x": "public.f(b)"},
+				UpdateTypes: map[string]query.OperandType{"x": query.OpFunc},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "public.g()", Operand2Type: query.OpFunc},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name:     "SELECT schema qualified function without name fails",
+			SQL:      "SELECT public.(a) FROM 't'",
+			Expected: query.Query{Type: query.Select},
+			Err:      fmt.Errorf("at SELECT: expected field to SELECT"),
+		},
+		{
+			Name: "SELECT FROM derived table works",
+			SQL:  "SELECT sub.x FROM (SELECT a AS x FROM 't' WHERE b = ')') AS sub WHERE sub.x > 1",
+			Expected: query.Query{
+				Type:   query.Select,
+				Fields: []string{"sub.x"}, Aliases: []string{""},
+				FromQuery: &query.Query{
+					Type:      query.Select,
+					TableName: "t",
+					Fields:    []string{"a"}, Aliases: []string{"x"},
+					Conditions: []query.Condition{
+						{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: ")", Operand2Type: query.OpQuoted},
+					},
+				},
+				FromAlias: "sub",
+				Conditions: []query.Condition{
+					{Operand1: "sub.x", Operand1Type: query.OpField, Operator: query.Gt, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT FROM derived table without alias fails",
+			SQL:      "SELECT x FROM (SELECT a AS x FROM 't') WHERE x = 1",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
-			Ended:    true,
+			Err:      fmt.Errorf("at FROM: subquery requires an alias"),
+		},
+		{
+			Name:     "SELECT FROM derived table with DELETE fails",
+			SQL:      "SELECT x FROM (DELETE FROM 't' WHERE a = 1) sub",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at FROM: expected SELECT in subquery"),
+		},
+		{
+			Name:     "SELECT FROM unclosed derived table fails",
+			SQL:      "SELECT x FROM (SELECT a FROM 't' sub",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at FROM: expected closing parens after subquery"),
+		},
+		{
+			Name:     "SELECT FROM ONLY fails",
+			SQL:      "SELECT a FROM ONLY 'b'",
+			Expected: query.Query{Type: query.Select, Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at SELECT: ONLY is only supported by the Postgres dialect"),
+		},
+		{
+			Name:     "BEGIN works",
+			SQL:      "BEGIN",
+			Expected: query.Query{Type: query.Begin},
+			Err:      nil,
+		},
+		{
+			Name:     "COMMIT TRANSACTION works",
+			SQL:      "COMMIT TRANSACTION",
+			Expected: query.Query{Type: query.Commit},
+			Err:      nil,
+		},
+		{
+			Name:     "ROLLBACK WORK works",
+			SQL:      "ROLLBACK WORK",
+			Expected: query.Query{Type: query.Rollback},
+			Err:      nil,
+		},
+		{
+			Name:     "SAVEPOINT works",
+			SQL:      "SAVEPOINT before_update",
+			Expected: query.Query{Type: query.Savepoint, SavepointName: "before_update"},
+			Err:      nil,
+		},
+		{
+			Name:     "SAVEPOINT without name fails",
+			SQL:      "SAVEPOINT",
+			Expected: query.Query{Type: query.Savepoint},
+			Err:      fmt.Errorf("at SAVEPOINT: expected savepoint name"),
+		},
+		{
+			Name: "SELECT with MATCH AGAINST works",
+			SQL:  "SELECT a FROM 'b' WHERE MATCH (title, body) AGAINST ('foo' IN BOOLEAN MODE) AND c = '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Conditions: []query.Condition{
+					{
+						Operand1List: []query.Operand{{Value: "title", Type: query.OpField}, {Value: "body", Type: query.OpField}},
+						Operand1Type: query.OpList,
+						Operator:     query.Match,
+						Operand2:     "foo",
+						Operand2Type: query.OpQuoted,
+						MatchMode:    "IN BOOLEAN MODE",
+					},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+				Fields:  []string{"a"},
+				Aliases: []string{""},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectMySQL},
+		},
+		{
+			Name: "SELECT with match function works without MySQL dialect",
+			SQL:  "SELECT a FROM 'b' WHERE match(x) = '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Aliases:   []string{""},
+				Conditions: []query.Condition{
+					{Operand1: "match(x)", Operand1Type: query.OpFunc, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with MATCH AGAINST fails without MySQL dialect",
+			SQL:  "SELECT a FROM 'b' WHERE MATCH(title) AGAINST ('foo')",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Aliases:    []string{""},
+				Conditions: []query.Condition{{Operand1: "MATCH(title)", Operand1Type: query.OpFunc}},
+			},
+			Err: fmt.Errorf("at WHERE: unknown operator"),
+		},
+		{
+			Name:     "SELECT with MATCH AGAINST with unknown modifier fails",
+			SQL:      "SELECT a FROM 'b' WHERE MATCH(title) AGAINST ('foo' IN FUZZY MODE)",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at WHERE: unknown search modifier 'IN FUZZY MODE'"),
+			Options:  Options{Dialect: DialectMySQL},
+		},
+		{
+			Name:     "SELECT with MATCH without AGAINST fails",
+			SQL:      "SELECT a FROM 'b' WHERE MATCH(title) = 'foo'",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      fmt.Errorf("at WHERE: expected AGAINST"),
+			Options:  Options{Dialect: DialectMySQL},
+		},
+		{
+			Name: "SELECT with window function works",
+			SQL:  "SELECT a, row_number() OVER (PARTITION BY a, b ORDER BY c DESC) AS rn, sum(d) over (order by e) FROM 'f'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "f",
+				Fields:    []string{"a", "row_number() OVER (PARTITION BY a, b ORDER BY c DESC)", "sum(d) over (order by e)"},
+				Aliases:   []string{"", "rn", ""},
+				Windows: []query.Window{
+					{Field: 1, PartitionBy: "a, b", OrderBy: "c DESC"},
+					{Field: 2, OrderBy: "e"},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with window function without parens fails",
+			SQL:      "SELECT row_number() OVER ORDER BY a FROM 'b'",
+			Expected: query.Query{Type: query.Select, Fields: []string{"row_number()"}},
+			Err:      fmt.Errorf("at SELECT: expected opening parens after OVER"),
+		},
+		{
+			Name:     "SELECT with unclosed window fails",
+			SQL:      "SELECT row_number() OVER (ORDER BY a FROM 'b'",
+			Expected: query.Query{Type: query.Select, Fields: []string{"row_number()"}},
+			Err:      fmt.Errorf("at SELECT: expected closing parens after OVER"),
+		},
+		{
+			Name:     "EXPLAIN SELECT works",
+			SQL:      "EXPLAIN SELECT a FROM 'b'",
+			Expected: query.Query{Type: query.Select, Explain: true, TableName: "b", Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      nil,
+		},
+		{
+			Name:     "EXPLAIN without statement fails",
+			SQL:      "EXPLAIN",
+			Expected: query.Query{Explain: true},
+			Err:      fmt.Errorf("at EXPLAIN: expected statement to explain"),
+		},
+		{
+			Name:     "DESCRIBE works",
+			SQL:      "DESCRIBE 'a'",
+			Expected: query.Query{Type: query.Describe, TableName: "a"},
+			Err:      nil,
+		},
+		{
+			Name:     "DESC with trailing token fails",
+			SQL:      "DESC 'a' b",
+			Expected: query.Query{Type: query.Describe, TableName: "a"},
+			Err:      fmt.Errorf("expected end of query"),
+		},
+		{
+			Name:     "COMMIT with trailing token fails",
+			SQL:      "COMMIT TRANSACTION a",
+			Expected: query.Query{Type: query.Commit},
+			Err:      fmt.Errorf("expected end of query"),
+		},
+		{
+			Name: "SELECT with comments works",
+			SQL:  "/* report */ SELECT a -- field\n FROM 'b' /* table */",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with kept comments works",
+			SQL:  "/* report */ SELECT a -- field\n FROM 'b' /* table */",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"}, Aliases: []string{""},
+				Comments: []query.Comment{
+					{Text: "/* report */", Pos: 0},
+					{Text: "-- field", Pos: 22},
+					{Text: "/* table */", Pos: 41},
+				},
+			},
+			Err:     nil,
+			Options: Options{KeepComments: true},
+		},
+		{
+			Name: "SELECT with inline comments between tokens works",
+			SQL:  "SELECT/* */a,/**/b/* c */FROM/**/'t'/**/WHERE/**/a/**/IN/**/('1')/**/AND/**/b>=/**/2/**/LIMIT/**/1",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "t",
+				Fields:    []string{"a", "b"}, Aliases: []string{"", ""},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.In, Operand2Type: query.OpList, Operand2List: []query.Operand{{Value: "1", Type: query.OpQuoted}}},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "2", Operand2Type: query.OpNumber},
+				},
+				Limit: int64Ptr(1),
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with inline comments between tokens works (Postgres)",
+			SQL:  "UPDATE/**/'a'/**/SET/**/b/**/=/**/'1'::/**/int/**/WHERE/**/c/**/=/**/INTERVAL/**/'1'/**/DAY",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "1"},
+				UpdateCasts: map[string]string{"b": "int"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "INTERVAL/**/'1'/**/DAY", Operand2Type: query.OpInterval},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "SELECT with WHERE with two conditions using AND works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1' AND b = '2'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"}, Aliases: []string{"", "", ""},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Ne, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "Empty UPDATE fails",
+			SQL:      "UPDATE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name fails",
+			SQL:      "UPDATE 'a'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name and SET fails",
+			SQL:      "UPDATE 'a' SET",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name, SET with a field but no value and WHERE fails",
+			SQL:      "UPDATE 'a' SET b WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected '='"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name, SET with a field and = but no value and WHERE fails",
+			SQL:      "UPDATE 'a' SET b = WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+		},
+		{
+			Name:     "Incomplete UPDATE due to no WHERE clause fails",
+			SQL:      "UPDATE 'a' SET b = 'hello' WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+		},
+		{
+			Name:     "Incomplete UPDATE due incomplete WHERE clause fails",
+			SQL:      "UPDATE 'a' SET b = 'hello' WHERE a",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: condition without operator"),
+		},
+		{
+			Name: "UPDATE works",
+			SQL:  "UPDATE 'a' SET b = 'hello' WHERE a = '1'",
+			Expected: query.Query{
+				Type:      query.Update,
+				TableName: "a",
+				Updates:   map[string]string{"b": "hello"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE works with simple quote inside",
+			SQL:  "UPDATE 'a' SET b = 'hello\\'world' WHERE a = '1'",
+			Expected: query.Query{
+				Type:      query.Update,
+				TableName: "a",
+				Updates:   map[string]string{"b": "hello'world"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE works with doubled quote inside",
+			SQL:  "UPDATE 'a' SET b = 'it''s', c = 'back\\\\' WHERE a = ''",
+			Expected: query.Query{
+				Type:      query.Update,
+				TableName: "a",
+				Updates:   map[string]string{"b": "it's", "c": "back\\"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with multiple SETs works",
+			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1'",
+			Expected: query.Query{
+				Type:      query.Update,
+				TableName: "a",
+				Updates:   map[string]string{"b": "hello", "c": "bye"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with multiple SETs and multiple conditions works",
+			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1' AND b = '789'",
+			Expected: query.Query{
+				Type:      query.Update,
+				TableName: "a",
+				Updates:   map[string]string{"b": "hello", "c": "bye"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "789", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with lowercase and newline separated conditions works",
+			SQL:  "update 'a'\nset b = '1'\nwhere a = '1'\n\tAnd\r\nb = '2'   and  c = '3'",
+			Expected: query.Query{
+				Type:      query.Update,
+				TableName: "a",
+				Updates:   map[string]string{"b": "1"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpQuoted},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "3", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with double quoted values works (MySQL)",
+			SQL:  "UPDATE 'a' SET b = \"2021-01-01\", c = \"say \"\"hi\"\"\" WHERE d = \"it's\"",
+			Expected: query.Query{
+				Type:      query.Update,
+				TableName: "a",
+				Updates:   map[string]string{"b": "2021-01-01", "c": "say \"hi\""},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "it's", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectMySQL},
+		},
+		{
+			Name:     "UPDATE with double quoted value fails",
+			SQL:      "UPDATE 'a' SET b = \"2021-01-01\" WHERE d = '1'",
+			Expected: query.Query{Type: query.Update, TableName: "a", Updates: map[string]string{}},
+			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+		},
+		{
+			Name: "INSERT with double quoted values works (MySQL)",
+			SQL:  "INSERT INTO 'a' (b, c) VALUES (\"1\", '2')",
+			Expected: query.Query{
+				Type:      query.Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c"},
+				Inserts:   [][]string{{"1", "2"}},
+			},
+			Err:     nil,
+			Options: Options{Dialect: DialectMySQL},
+		},
+		{
+			Name:     "UPDATE with duplicate assignment fails",
+			SQL:      "UPDATE 'a' SET b = '1', c = '2', b = '3' WHERE a = '1'",
+			Expected: query.Query{Type: query.Update, TableName: "a", Updates: map[string]string{"b": "1", "c": "2"}},
+			Err:      fmt.Errorf("at UPDATE: duplicate assignment to column 'b'"),
+		},
+		{
+			Name: "UPDATE with column reference works",
+			SQL:  "UPDATE 'a' SET b = c, d = 1, e = 'x' WHERE a = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "c", "d": "1", "e": "x"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpField, "d": query.OpNumber},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with niladic function works",
+			SQL:  "UPDATE 'a' SET b = CURRENT_TIMESTAMP WHERE a = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "CURRENT_TIMESTAMP"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpFunc},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with NULL and boolean values works",
+			SQL:  "UPDATE 'a' SET b = null, c = True, d = FALSE WHERE a = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "NULL", "c": "TRUE", "d": "FALSE"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpNull, "c": query.OpBool, "d": query.OpBool},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "Empty DELETE fails",
+			SQL:      "DELETE FROM",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name:     "DELETE without WHERE fails",
+			SQL:      "DELETE FROM 'a'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name:     "DELETE with empty WHERE fails",
+			SQL:      "DELETE FROM 'a' WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+		},
+		{
+			Name:     "DELETE with WHERE with field but no operator fails",
+			SQL:      "DELETE FROM 'a' WHERE b",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: condition without operator"),
+		},
+		{
+			Name: "DELETE with WHERE works",
+			SQL:  "DELETE FROM 'a' WHERE b = '1'",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "a",
+				Conditions: []query.Condition{
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "Empty INSERT fails",
+			SQL:      "INSERT INTO",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name:     "INSERT with no rows to insert fails",
+			SQL:      "INSERT INTO 'a'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails",
+			SQL:      "INSERT INTO 'a' (",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails #2",
+			SQL:      "INSERT INTO 'a' (b",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails #3",
+			SQL:      "INSERT INTO 'a' (b)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails #4",
+			SQL:      "INSERT INTO 'a' (b) VALUES",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with short row fails",
+			SQL:      "INSERT INTO 'a' (b, c, d) VALUES ('1', '2', '3'), ('4', '5', '6'), ('7', '8')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: row 3 has 2 values, expected 3"),
+		},
+		{
+			Name:     "INSERT with long row fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES ('1', '2')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: row 1 has 2 values, expected 1"),
+		},
+		{
+			Name:     "INSERT with incomplete row fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES (",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: value count doesn't match field count"),
+		},
+		{
+			Name: "INSERT works",
+			SQL:  "INSERT INTO 'a' (b) VALUES ('1')",
+			Expected: query.Query{
+				Type:      query.Insert,
+				TableName: "a",
+				Fields:    []string{"b"},
+				Inserts:   [][]string{{"1"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT * fails",
+			SQL:  "INSERT INTO 'a' (*) VALUES ('1')",
+			Expected: query.Query{
+				Type:      query.Insert,
+				TableName: "a",
+				Fields:    []string{"*"},
+				Inserts:   [][]string{{"1"}},
+			},
+			Err: fmt.Errorf("at INSERT INTO: expected at least one field to insert"),
+		},
+		{
+			Name: "INSERT with multiple fields works",
+			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' )",
+			Expected: query.Query{
+				Type:      query.Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c", "d"},
+				Inserts:   [][]string{{"1", "2", "3"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with multiple fields and multiple values works",
+			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' ),('4','5' ,'6' )",
+			Expected: query.Query{
+				Type:      query.Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c", "d"},
+				Inserts:   [][]string{{"1", "2", "3"}, {"4", "5", "6"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "ALTER TABLE with ADD and DROP COLUMN works",
+			SQL:  "ALTER TABLE 'a' ADD COLUMN b INT, drop column c, ADD d VARCHAR(255)",
+			Expected: query.Query{
+				Type:      query.Alter,
+				TableName: "a",
+				AlterActions: []query.AlterAction{
+					{Action: query.AddColumn, Column: "b", ColumnType: "INT"},
+					{Action: query.DropColumn, Column: "c"},
+					{Action: query.AddColumn, Column: "d", ColumnType: "VARCHAR(255)"},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "ALTER without TABLE fails",
+			SQL:      "ALTER 'a' ADD COLUMN b INT",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ALTER: expected TABLE, got A"),
+		},
+		{
+			Name:     "ALTER TABLE without action fails",
+			SQL:      "ALTER TABLE 'a'",
+			Expected: query.Query{Type: query.Alter, TableName: "a"},
+			Err:      fmt.Errorf("at ALTER TABLE: need at least one action"),
+		},
+		{
+			Name:     "ALTER TABLE with ADD COLUMN without type fails",
+			SQL:      "ALTER TABLE 'a' ADD COLUMN b",
+			Expected: query.Query{Type: query.Alter, TableName: "a", AlterActions: []query.AlterAction{{Action: query.AddColumn, Column: "b"}}},
+			Err:      fmt.Errorf("at ALTER TABLE: expected column type"),
+		},
+		{
+			Name:     "ALTER TABLE with ALTER COLUMN fails",
+			SQL:      "ALTER TABLE 'a' ALTER COLUMN b TYPE INT",
+			Expected: query.Query{Type: query.Alter, TableName: "a"},
+			Err:      fmt.Errorf("at ALTER TABLE: expected ADD or DROP"),
+		},
+	}
+
+	output := output{Types: query.TypeString, Operators: query.OperatorString}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual, err := ParseManyWithOptions([]string{tc.SQL}, tc.Options)
+			if err != nil {
+				if errPos, ok := err.(*ErrorWithPos); ok {
+					fmt.Fprintln(os.Stderr, "")
+					errPos.PrintPosError(tc.SQL, os.Stderr)
+				}
+			}
+			if tc.Err != nil && err == nil {
+				t.Errorf("Error should have been %v", tc.Err)
+			}
+			if tc.Err == nil && err != nil {
+				t.Errorf("Error should have been nil but was %v", err)
+			}
+			if tc.Err != nil && err != nil {
+				require.Equal(t, tc.Err.Error(), err.Error(), "Unexpected error")
+			}
+			if len(actual) > 0 {
+				require.Equal(t, tc.Expected, actual[0], "Query didn't match expectation")
+			}
+			if tc.Err != nil {
+				output.ErrorExamples = append(output.ErrorExamples, tc)
+			} else {
+				output.NoErrorExamples = append(output.NoErrorExamples, tc)
+			}
+		})
+	}
+	createReadme(output)
+}
+
+func TestWhere(t *testing.T) {
+	ts := []testCase{
+		{
+			Name:     "empty query fails",
+			SQL:      "",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+			Ended:    true,
+		},
+		{
+			Name: "WHERE a",
+			SQL:  "a ",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.UnknownOperator, Operand2: "", Operand2Type: query.OpUnknown},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a = x'1F' AND b IN (B'1010', '1')",
+			SQL:  "a = x'1F' AND b IN (B'1010', '1')",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "x'1F'", Operand2Type: query.OpHex},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.In, Operand2Type: query.OpList, Operand2List: []query.Operand{{Value: "B'1010'", Type: query.OpBit}, {Value: "1", Type: query.OpQuoted}}},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "ERROR WHERE a = x'1G'",
+			SQL:  "a = x'1G'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: invalid hex literal"),
+			Ended: false,
+		},
+		{
+			Name: "ERROR WHERE a = b'102'",
+			SQL:  "a = b'102'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: invalid bit literal"),
+			Ended: false,
+		},
+		{
+			Name: "WHERE a > INTERVAL '1' DAY AND b < interval '2 hours'",
+			SQL:  "a > INTERVAL '1' DAY AND b < interval '2 hours'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gt, Operand2: "INTERVAL '1' DAY", Operand2Type: query.OpInterval},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Lt, Operand2: "interval '2 hours'", Operand2Type: query.OpInterval},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE ts > now() - INTERVAL '1 day'",
+			SQL:  "ts > now() - INTERVAL '1 day'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "ts", Operand1Type: query.OpField, Operator: query.Gt, Operand2: "now() - INTERVAL '1 day'", Operand2Type: query.OpExpr},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a < b + 1 * c AND d = '1'",
+			SQL:  "a < b + 1 * c AND d = '1'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Lt, Operand2: "b + 1 * c", Operand2Type: query.OpExpr},
+					{Operand1: "d", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "ERROR WHERE a > b -",
+			SQL:  "a > b -",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gt},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: expected operand after arithmetic operator"),
+			Ended: false,
+		},
+		{
+			Name: "ERROR WHERE a > INTERVAL 1",
+			SQL:  "a > INTERVAL 1",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gt},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: expected quoted value after INTERVAL"),
+			Ended: false,
+		},
+		{
+			Name: "WHERE a = '1' AND b = '2' on separate lines",
+			SQL:  "a = '1'\nAND\nb = '2'\nand c = '3'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpQuoted},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "3", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a = ''",
+			SQL:  "a = ''",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a = 1",
+			SQL:  "a>=1",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a = 1.24",
+			SQL:  "a>= 1.24",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "1.24", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a = -1.21",
+			SQL:  "a>=-1.21",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "-1.21", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a='1' without spaces",
+			SQL:  "a='1'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a>='1' without spaces",
+			SQL:  "a>='1'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a!='1' AND b<>'2' without spaces",
+			SQL:  "a!='1' AND b<>'2'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Ne, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Ne, Operand2: "2", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE '1'<=a AND b <>c with mixed spaces",
+			SQL:  "'1'<=a AND b <>c",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "1", Operand1Type: query.OpQuoted, Operator: query.Lte, Operand2: "a", Operand2Type: query.OpField},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Ne, Operand2: "c", Operand2Type: query.OpField},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a = 1 AND b > a1",
+			SQL:  "a = 1 AND b > a1",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Gt, Operand2: "a1", Operand2Type: query.OpField},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "ERROR (a1) WHERE a = 1a",
+			SQL:  "a = 1a",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "", Operand2Type: query.OpUnknown},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: expected quoted value"),
+			Ended: false,
+		},
+		{
+			Name: "ERROR WHERE a = 1a (ValidateNumbers)",
+			SQL:  "a = 1a",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq},
+				},
+			},
+			Err:     fmt.Errorf("at WHERE: invalid number '1a'"),
+			Ended:   false,
+			Options: Options{ValidateNumbers: true},
+		},
+		{
+			Name: "ERROR WHERE a IN (1, 1.2.3) (ValidateNumbers)",
+			SQL:  "a IN (1, 1.2.3)",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.In},
+				},
+			},
+			Err:     fmt.Errorf("at WHERE: invalid number '1.2.3'"),
+			Ended:   false,
+			Options: Options{ValidateNumbers: true},
+		},
+		{
+			Name: "WHERE a = 1.2.3",
+			SQL:  "a = 1.2.3",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1.2.3", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a = -1.24 (ValidateNumbers)",
+			SQL:  "a = -1.24",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "-1.24", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:     nil,
+			Ended:   true,
+			Options: Options{ValidateNumbers: true},
+		},
+		{
+			Name: "WHERE lower(a) = lower(b) AND length(c) > 1",
+			SQL:  "lower(a) = lower(b) AND length(c) > 1",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "lower(a)", Operand1Type: query.OpFunc, Operator: query.Eq, Operand2: "lower(b)", Operand2Type: query.OpFunc},
+					{Operand1: "length(c)", Operand1Type: query.OpFunc, Operator: query.Gt, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE created < CURRENT_TIMESTAMP AND d >= current_date AND t != CURRENT_TIME AND n < NOW()",
+			SQL:  "created < CURRENT_TIMESTAMP AND d >= current_date AND t != CURRENT_TIME AND n < NOW()",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "created", Operand1Type: query.OpField, Operator: query.Lt, Operand2: "CURRENT_TIMESTAMP", Operand2Type: query.OpFunc},
+					{Operand1: "d", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "current_date", Operand2Type: query.OpFunc},
+					{Operand1: "t", Operand1Type: query.OpField, Operator: query.Ne, Operand2: "CURRENT_TIME", Operand2Type: query.OpFunc},
+					{Operand1: "n", Operand1Type: query.OpField, Operator: query.Lt, Operand2: "NOW()", Operand2Type: query.OpFunc},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE 'X' = upper(a)",
+			SQL:  "'X' = upper(a)",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "X", Operand1Type: query.OpQuoted, Operator: query.Eq, Operand2: "upper(a)", Operand2Type: query.OpFunc},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE (a + b) > '10' AND c <= (d * (e - 1))",
+			SQL:  "(a + b) > '10' AND c <= (d * (e - 1))",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "(a + b)", Operand1Type: query.OpExpr, Operator: query.Gt, Operand2: "10", Operand2Type: query.OpQuoted},
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Lte, Operand2: "(d * (e - 1))", Operand2Type: query.OpExpr},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE NOT (a - 1) = 2 AND NOT (b = ')+')",
+			SQL:  "NOT (a - 1) = 2 AND NOT (b = ')+')",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "(a - 1)", Operand1Type: query.OpExpr, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpNumber, Not: true},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2: ")+", Operand2Type: query.OpQuoted, Not: true},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a IN ('1', 2, b)",
+			SQL:  "a IN ('1', 2, b)",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{
+						Operand1: "a", Operand1Type: query.OpField, Operator: query.In, Operand2Type: query.OpList,
+						Operand2List: []query.Operand{{Value: "1", Type: query.OpQuoted}, {Value: "2", Type: query.OpNumber}, {Value: "b", Type: query.OpField}},
+					},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE a not in('1','2') AND b LIKE 'x%'",
+			SQL:  "a not in('1','2') AND b LIKE 'x%'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{
+						Operand1: "a", Operand1Type: query.OpField, Operator: query.NotIn, Operand2Type: query.OpList,
+						Operand2List: []query.Operand{{Value: "1", Type: query.OpQuoted}, {Value: "2", Type: query.OpQuoted}},
+					},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Like, Operand2: "x%", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE NOT (a IN ('1','2')) AND a NOT LIKE 'x%'",
+			SQL:  "NOT (a IN ('1','2')) AND a NOT LIKE 'x%'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{
+						Operand1: "a", Operand1Type: query.OpField, Operator: query.In, Operand2Type: query.OpList,
+						Operand2List: []query.Operand{{Value: "1", Type: query.OpQuoted}, {Value: "2", Type: query.OpQuoted}},
+						Not:          true,
+					},
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.NotLike, Operand2: "x%", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "WHERE NOT a LIKE 'x%'",
+			SQL:  "NOT a LIKE 'x%'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Like, Operand2: "x%", Operand2Type: query.OpQuoted, Not: true},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "ERROR WHERE NOT (a LIKE 'x%'",
+			SQL:  "NOT (a LIKE 'x%'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Like, Operand2: "x%", Operand2Type: query.OpQuoted, Not: true},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: expected closing parens"),
+			Ended: true,
+		},
+		{
+			Name: "ERROR WHERE a NOT = '1'",
+			SQL:  "a NOT = '1'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: expected IN or LIKE after NOT"),
+			Ended: false,
+		},
+		{
+			Name: "ERROR WHERE a IN ('1',",
+			SQL:  "a IN ('1',",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.In},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: expected value in list"),
+			Ended: false,
+		},
+		{
+			Name:     "ERROR WHERE data->'a (Postgres)",
+			SQL:      "data->'a",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected JSON path key"),
+			Ended:    false,
+			Options:  Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "WHERE a > ANY ('1', 2) AND b = all(c, d)",
+			SQL:  "a > ANY ('1', 2) AND b = all(c, d)",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{
+						Operand1: "a", Operand1Type: query.OpField, Operator: query.Gt, Operand2Type: query.OpList,
+						Operand2List: []query.Operand{{Value: "1", Type: query.OpQuoted}, {Value: "2", Type: query.OpNumber}},
+						Quantifier:   query.Any,
+					},
+					{
+						Operand1: "b", Operand1Type: query.OpField, Operator: query.Eq, Operand2Type: query.OpList,
+						Operand2List: []query.Operand{{Value: "c", Type: query.OpField}, {Value: "d", Type: query.OpField}},
+						Quantifier:   query.All,
+					},
+				},
+			},
+			Err:   nil,
+			Ended: true,
+		},
+		{
+			Name: "ERROR WHERE a > ANY '1'",
+			SQL:  "a > ANY '1'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gt},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: expected opening parens"),
+			Ended: false,
 		},
 		{
-			Name: "WHERE a",
-			SQL:  "a ",
+			Name: "WHERE a LIKE 'x!%y' ESCAPE '!' AND b NOT LIKE '10\\%' escape '\\'",
+			SQL:  "a LIKE 'x!%y' ESCAPE '!' AND b NOT LIKE '10\\%' escape '\\\\'",
 			Expected: query.Query{
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.UnknownOperator, Operand2: "", Operand2Type: query.OpUnknown},
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Like, Operand2: "x!%y", Operand2Type: query.OpQuoted, Escape: "!"},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.NotLike, Operand2: "10\\%", Operand2Type: query.OpQuoted, Escape: "\\"},
 				},
 			},
 			Err:   nil,
 			Ended: true,
 		},
 		{
-			Name: "WHERE a = ''",
-			SQL:  "a = ''",
+			Name: "ERROR WHERE a LIKE 'x' ESCAPE '!!'",
+			SQL:  "a LIKE 'x' ESCAPE '!!'",
 			Expected: query.Query{
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "", Operand2Type: query.OpQuoted},
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Like},
 				},
 			},
-			Err:   nil,
-			Ended: true,
+			Err:   fmt.Errorf("at WHERE: expected single quoted character after ESCAPE"),
+			Ended: false,
 		},
 		{
-			Name: "WHERE a = 1",
-			SQL:  "a>=1",
+			Name: "ERROR WHERE a LIKE 'x' ESCAPE b",
+			SQL:  "a LIKE 'x' ESCAPE b",
 			Expected: query.Query{
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "1", Operand2Type: query.OpNumber},
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Like},
 				},
 			},
-			Err:   nil,
-			Ended: true,
+			Err:   fmt.Errorf("at WHERE: expected single quoted character after ESCAPE"),
+			Ended: false,
 		},
 		{
-			Name: "WHERE a = 1.24",
-			SQL:  "a>= 1.24",
+			Name: "WHERE (a, b) IN (('1', '2'), ('3', 4))",
+			SQL:  "(a, b) IN (('1', '2'), ('3', 4))",
 			Expected: query.Query{
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "1.24", Operand2Type: query.OpNumber},
+					{
+						Operand1List: []query.Operand{{Value: "a", Type: query.OpField}, {Value: "b", Type: query.OpField}},
+						Operand1Type: query.OpTuple,
+						Operator:     query.In,
+						Operand2Type: query.OpList,
+						Operand2List: []query.Operand{
+							{Type: query.OpTuple, Tuple: []query.Operand{{Value: "1", Type: query.OpQuoted}, {Value: "2", Type: query.OpQuoted}}},
+							{Type: query.OpTuple, Tuple: []query.Operand{{Value: "3", Type: query.OpQuoted}, {Value: "4", Type: query.OpNumber}}},
+						},
+					},
 				},
 			},
 			Err:   nil,
 			Ended: true,
 		},
 		{
-			Name: "WHERE a = -1.21",
-			SQL:  "a>=-1.21",
+			Name: "ERROR WHERE (a, b) IN (('1', '2'), ('3'))",
+			SQL:  "(a, b) IN (('1', '2'), ('3'))",
 			Expected: query.Query{
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Gte, Operand2: "-1.21", Operand2Type: query.OpNumber},
+					{
+						Operand1List: []query.Operand{{Value: "a", Type: query.OpField}, {Value: "b", Type: query.OpField}},
+						Operand1Type: query.OpTuple,
+						Operator:     query.In,
+					},
 				},
 			},
-			Err:   nil,
-			Ended: true,
+			Err:   fmt.Errorf("at WHERE: expected row with 2 values, got 1"),
+			Ended: false,
 		},
 		{
-			Name: "WHERE a = 1 AND b > a1",
-			SQL:  "a = 1 AND b > a1",
+			Name: "ERROR WHERE (a, b) = '1'",
+			SQL:  "(a, b) = '1'",
 			Expected: query.Query{
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
-					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Gt, Operand2: "a1", Operand2Type: query.OpField},
+					{
+						Operand1List: []query.Operand{{Value: "a", Type: query.OpField}, {Value: "b", Type: query.OpField}},
+						Operand1Type: query.OpTuple,
+					},
 				},
 			},
-			Err:   nil,
-			Ended: true,
+			Err:   fmt.Errorf("at WHERE: expected IN after row constructor"),
+			Ended: false,
 		},
 		{
-			Name: "ERROR (a1) WHERE a = 1a",
-			SQL:  "a = 1a",
+			Name: "WHERE a IS DISTINCT FROM b (Postgres)",
+			SQL:  "a IS DISTINCT FROM b",
 			Expected: query.Query{
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "", Operand2Type: query.OpUnknown},
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.IsDistinctFrom, Operand2: "b", Operand2Type: query.OpField},
 				},
 			},
-			Err:   fmt.Errorf("at WHERE: expected quoted value"),
+			Err:     nil,
+			Ended:   true,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "WHERE a is not distinct from '1' (Postgres)",
+			SQL:  "a is not distinct from '1'",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.IsNotDistinctFrom, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err:     nil,
+			Ended:   true,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "ERROR WHERE a IS NOT FROM b (Postgres)",
+			SQL:  "a IS NOT FROM b",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.UnknownOperator, Operand2: "", Operand2Type: query.OpUnknown},
+				},
+			},
+			Err:     fmt.Errorf("at WHERE: expected DISTINCT"),
+			Ended:   false,
+			Options: Options{Dialect: DialectPostgres},
+		},
+		{
+			Name: "ERROR WHERE a IS DISTINCT FROM b",
+			SQL:  "a IS DISTINCT FROM b",
+			Expected: query.Query{
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.UnknownOperator, Operand2: "", Operand2Type: query.OpUnknown},
+				},
+			},
+			Err:   fmt.Errorf("at WHERE: unknown operator"),
 			Ended: false,
 		},
 	}
@@ -583,6 +2187,7 @@ func TestWhere(t *testing.T) {
 			p.step = stepWhereField
 			p.sql = tc.SQL
 			p.sqlUpper = strings.ToUpper(tc.SQL)
+			p.opts = tc.Options
 
 			ended, err := p.parseWhere()
 			if err != nil {
@@ -608,6 +2213,309 @@ func TestWhere(t *testing.T) {
 	}
 }
 
+func TestOnStatement(t *testing.T) {
+	type call struct {
+		sql string
+		q   query.Query
+		err error
+	}
+	var calls []call
+	opts := Options{
+		OnStatement: func(sql string, q query.Query, err error, dur time.Duration) {
+			require.True(t, dur >= 0, "negative duration")
+			calls = append(calls, call{sql: sql, q: q, err: err})
+		},
+	}
+	sqls := []string{"SELECT a FROM 'b'", "DELETE FROM 'a'", "SELECT c FROM 'd'"}
+
+	expected, expectedErr := ParseMany(sqls)
+	actual, err := ParseManyWithOptions(sqls, opts)
+	require.Equal(t, expectedErr, err)
+	require.Equal(t, expected, actual)
+
+	require.Equal(t, 2, len(calls), "callback must be called for each parsed statement")
+	require.Equal(t, sqls[0], calls[0].sql)
+	require.Equal(t, expected[0], calls[0].q)
+	require.NoError(t, calls[0].err)
+	require.Equal(t, sqls[1], calls[1].sql)
+	require.EqualError(t, calls[1].err, "at WHERE: WHERE clause is mandatory for UPDATE & DELETE")
+}
+
+func TestParseScript(t *testing.T) {
+	script := `
+-- setup; not a separator
+INSERT INTO 'a' (b, c) VALUES (';not a separator', 'it''s; \'quoted\'');
+/* ; */ SELECT b FROM 'a' WHERE b = 'x;y';;
+UPDATE 'a' SET b = '1' WHERE c = '2' -- trailing;comment
+`
+	qs, err := ParseScript(script)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(qs))
+	require.Equal(t, [][]string{{";not a separator", "it's; 'quoted'"}}, qs[0].Inserts)
+	require.Equal(t, "x;y", qs[1].Conditions[0].Operand2)
+	require.Equal(t, query.Update, qs[2].Type)
+
+	require.Equal(t, []query.Span{{Start: 0, End: 13}, {Start: 16, End: 27}}, splitScript("SELECT \"a;\" b ; `c;d` /* */;-- e;", 0))
+	require.Equal(t, []query.Span{{Start: 0, End: 15}, {Start: 16, End: 17}}, splitScript(`SELECT "a\";" b;c`, '"'))
+
+	script = "SELECT a FROM 'b'; DELETE FROM 'c'"
+	qs, err = ParseScript(script)
+	require.Equal(t, 1, len(qs))
+	require.EqualError(t, err, "at WHERE: WHERE clause is mandatory for UPDATE & DELETE")
+	require.Equal(t, len(script), err.(*ErrorWithPos).Pos())
+
+	var calls []string
+	opts := Options{
+		OnStatement: func(sql string, q query.Query, err error, dur time.Duration) {
+			calls = append(calls, sql)
+		},
+	}
+	_, err = ParseScriptWithOptions("SELECT a FROM 'b';SELECT c FROM 'd';", opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"SELECT a FROM 'b'", "SELECT c FROM 'd'"}, calls)
+}
+
+func TestParseScriptWithOffsets(t *testing.T) {
+	script := "SELECT a FROM 'b';\n  UPDATE 'c' SET d = ';' WHERE e = '1' ;\nDELETE FROM 'f' WHERE g"
+	qs, spans, err := ParseScriptWithOffsets(script)
+	require.Equal(t, 2, len(qs))
+	require.Equal(t, []query.Span{{Start: 0, End: 17}, {Start: 21, End: 57}}, spans)
+	for i, span := range spans {
+		q, err := Parse(script[span.Start:span.End])
+		require.NoError(t, err)
+		require.Equal(t, q, qs[i])
+	}
+	require.EqualError(t, err, "at WHERE: condition without operator")
+	require.Equal(t, len(script), err.(*ErrorWithPos).Pos(), "error position must be relative to the script")
+}
+
+func TestString(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected string
+		Options  Options
+	}{
+		{SQL: "select a, b as c from 'd'", Expected: "SELECT a, b AS c FROM 'd'"},
+		{SQL: "select a as 'My Column', b as \"from\" from 'd'", Expected: "SELECT a AS \"My Column\", b AS \"from\" FROM 'd'"},
+		{SQL: "select a from 'd' offset 10 limit 5", Expected: "SELECT a FROM 'd' LIMIT 5 OFFSET 10"},
+		{SQL: "select a from 'd' where b = 1 limit 5 offset 10", Expected: "SELECT a FROM 'd' WHERE b = 1 LIMIT 5 OFFSET 10"},
+		{SQL: "select top 5 a from 'd'", Expected: "SELECT a FROM 'd' LIMIT 5", Options: Options{Dialect: DialectSQLServer}},
+		{SQL: "select top 10 a", Expected: "SELECT TOP 10 a", Options: Options{Dialect: DialectSQLServer}},
+		{SQL: "delete from 'a' where b collate \"C\" like 'x!%' escape '!'", Expected: "DELETE FROM 'a' WHERE b LIKE 'x!%' COLLATE \"C\" ESCAPE '!'"},
+		{SQL: "select a from 'd' where b = ? limit ? offset $3", Expected: "SELECT a FROM 'd' WHERE b = ? LIMIT ? OFFSET $3"},
+		{SQL: "update 'a' set b = ? where c = $2", Expected: "UPDATE 'a' SET b = ? WHERE c = $2"},
+		{SQL: "select s.a from (select a from 'b' where c in (1, 2)) as s", Expected: "SELECT s.a FROM (SELECT a FROM 'b' WHERE c IN (1, 2)) s"},
+		{SQL: "select distinct a from 'd'", Expected: "SELECT DISTINCT a FROM 'd'"},
+		{SQL: "select case when a = 1 then 'x' end as b from 'd'", Expected: "SELECT case when a = 1 then 'x' end AS b FROM 'd'"},
+		{SQL: "update 'a' set x = b.y from 'b', 'c' where a.id = b.id", Expected: "UPDATE 'a' SET x = b.y FROM 'b', 'c' WHERE a.id = b.id", Options: Options{Dialect: DialectPostgres}},
+		{SQL: "delete from 'a' using 'b', 'c' where a.id = b.id", Expected: "DELETE FROM 'a' USING 'b', 'c' WHERE a.id = b.id", Options: Options{Dialect: DialectPostgres}},
+		{SQL: "select public.f(a) from 't' where public.g(b) = 1", Expected: "SELECT public.f(a) FROM 't' WHERE public.g(b) = 1"},
+		{SQL: "select a from 't' where not (a + b) > '10' and c = (d * 2)", Expected: "SELECT a FROM 't' WHERE NOT ((a + b) > '10') AND c = (d * 2)"},
+		{SQL: "select a from 't' where a::int = '1'::int and b in ($1::date) and c > '1 day'::interval", Expected: "SELECT a FROM 't' WHERE a::int = '1'::int AND b IN ($1::date) AND c > '1 day'::interval", Options: Options{Dialect: DialectPostgres}},
+		{SQL: "update 'a' set b = '1' :: numeric(10, 2) where c = 1", Expected: "UPDATE 'a' SET b = '1'::numeric(10, 2) WHERE c = 1", Options: Options{Dialect: DialectPostgres}},
+		{SQL: "select/**/a/**/from/**/'t'/**/where/**/a/**/=/**/'1'", Expected: "SELECT a FROM 't' WHERE a = '1'"},
+		{SQL: `select "s".t."c" from s."t" where "s".t.d = 1`, Expected: "SELECT s.t.c FROM 's.t' WHERE s.t.d = 1", Options: Options{Dialect: DialectPostgres}},
+		{SQL: `SELECT "My Schema".users.id, "x""y" FROM t WHERE "My Schema"."Ü".a = '1'`, Expected: `SELECT "My Schema".users.id, "x""y" FROM 't' WHERE "My Schema"."Ü".a = '1'`, Options: Options{Dialect: DialectPostgres}},
+		{SQL: `SELECT "a,b", "select", t."end", "it's", "a-b", count(*), * FROM 'b' WHERE "select" = 'x' AND "it's" = 'x' AND "a-b" = 'x' AND c = "d e"`, Expected: `SELECT "a,b", "select", t."end", "it's", "a-b", count(*), * FROM 'b' WHERE "select" = 'x' AND "it's" = 'x' AND "a-b" = 'x' AND c = "d e"`},
+		{SQL: `select a, column from 'b'`, Expected: `SELECT a, "column" FROM 'b'`},
+		{SQL: `UPDATE 'a' SET "b c" = '1', "from" = d WHERE "where" = '2'`, Expected: `UPDATE 'a' SET "b c" = '1', "from" = d WHERE "where" = '2'`},
+		{SQL: `INSERT INTO 'a' ("b c", "select") VALUES ('1', '2')`, Expected: `INSERT INTO 'a' ("b c", "select") VALUES ('1', '2')`},
+		{SQL: "update only 'a' set b = '1' where c = '2'", Expected: "UPDATE ONLY 'a' SET b = '1' WHERE c = '2'", Options: Options{Dialect: DialectPostgres}},
+		{SQL: "select distinct top 10 percent a from 'd'", Expected: "SELECT DISTINCT TOP 10 PERCENT a FROM 'd'", Options: Options{Dialect: DialectSQLServer}},
+		{SQL: "SELECT version()", Expected: "SELECT version()"},
+		{SQL: "SELECT a FROM 'b' WHERE a >= 1 AND c = d", Expected: "SELECT a FROM 'b' WHERE a >= 1 AND c = d"},
+		{SQL: "SELECT a FROM 'b' WHERE a NOT IN ('1',2) AND a NOT LIKE 'x%'", Expected: "SELECT a FROM 'b' WHERE a NOT IN ('1', 2) AND a NOT LIKE 'x%'"},
+		{SQL: "SELECT a FROM 'b' WHERE NOT (a IN ('1', '2')) AND NOT a LIKE 'x%'", Expected: "SELECT a FROM 'b' WHERE NOT (a IN ('1', '2')) AND NOT (a LIKE 'x%')"},
+		{SQL: "UPDATE 'a' SET c = 'bye', b = 'hello\\'world' WHERE a = '1'", Expected: "UPDATE 'a' SET b = 'hello''world', c = 'bye' WHERE a = '1'"},
+		{SQL: `SELECT a FROM 'b' WHERE c = 'x\\'' OR 1=1 --' AND d LIKE 'e\%' ESCAPE '\\'`, Expected: `SELECT a FROM 'b' WHERE c = 'x\\'' OR 1=1 --' AND d LIKE 'e\%' ESCAPE '\\'`},
+		{SQL: "UPDATE 'a' SET b = 'it''s' WHERE a = 'back\\\\'", Expected: "UPDATE 'a' SET b = 'it''s' WHERE a = 'back\\\\'"},
+		{SQL: "UPDATE 'a' SET c = d, b = 2 WHERE a = '1'", Expected: "UPDATE 'a' SET b = 2, c = d WHERE a = '1'"},
+		{SQL: "INSERT INTO 'a' (b,c) VALUES ('1','2'),('3','4')", Expected: "INSERT INTO 'a' (b, c) VALUES ('1', '2'), ('3', '4')"},
+		{SQL: "DELETE FROM 'a' WHERE b != '1'", Expected: "DELETE FROM 'a' WHERE b != '1'"},
+		{SQL: "DELETE FROM 'a' WHERE b >= ALL ('1', 2)", Expected: "DELETE FROM 'a' WHERE b >= ALL ('1', 2)"},
+		{SQL: "DELETE FROM 'a' WHERE b LIKE 'x!%' ESCAPE '!' AND c LIKE 'y' ESCAPE '\\\\'", Expected: "DELETE FROM 'a' WHERE b LIKE 'x!%' ESCAPE '!' AND c LIKE 'y' ESCAPE '\\\\'"},
+		{SQL: "DELETE FROM 'a' WHERE (b, c) NOT IN (('1', 2), (d, '3'))", Expected: "DELETE FROM 'a' WHERE (b, c) NOT IN (('1', 2), (d, '3'))"},
+		{SQL: "UPDATE 'a' SET b = X'0f' WHERE c IN (b'1', x'')", Expected: "UPDATE 'a' SET b = X'0f' WHERE c IN (b'1', x'')"},
+		{SQL: "DELETE FROM 'a' WHERE b < interval '1 day'", Expected: "DELETE FROM 'a' WHERE b < interval '1 day'"},
+		{SQL: "UPDATE 'a' SET b = current_timestamp WHERE c IN (CURRENT_DATE, now())", Expected: "UPDATE 'a' SET b = current_timestamp WHERE c IN (CURRENT_DATE, now())"},
+		{SQL: "UPDATE 'a' SET b = null WHERE c = '1'", Expected: "UPDATE 'a' SET b = NULL WHERE c = '1'"},
+		{SQL: "SELECT a FROM 'b' WHERE ts > now() - INTERVAL '1 day'", Expected: "SELECT a FROM 'b' WHERE ts > now() - INTERVAL '1 day'"},
+		{SQL: "DELETE FROM 'a' WHERE lower(b) = lower(c)", Expected: "DELETE FROM 'a' WHERE lower(b) = lower(c)"},
+		{SQL: "select count(distinct a), count(all b), count(*) from 'c'", Expected: "SELECT count(distinct a), count(all b), count(*) FROM 'c'"},
+		{SQL: "ALTER TABLE 'a' ADD b INT,DROP COLUMN c", Expected: "ALTER TABLE 'a' ADD COLUMN b INT, DROP COLUMN c"},
+		{SQL: "begin transaction", Expected: "BEGIN"},
+		{SQL: "explain delete from 'a' where b = '1'", Expected: "EXPLAIN DELETE FROM 'a' WHERE b = '1'"},
+		{SQL: "desc 'a'", Expected: "DESCRIBE 'a'"},
+		{SQL: "select rank() over (partition by a order by lower(b), ')') from 'c'", Expected: "SELECT rank() over (partition by a order by lower(b), ')') FROM 'c'"},
+		{SQL: "delete from 'a' where not match(b,c) against ('x') and match(d) against ('y' with query expansion)", Expected: "DELETE FROM 'a' WHERE NOT (MATCH (b, c) AGAINST ('x')) AND MATCH (d) AGAINST ('y' WITH QUERY EXPANSION)", Options: Options{Dialect: DialectMySQL}},
+		{SQL: "savepoint s1", Expected: "SAVEPOINT s1"},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := ParseWithOptions(tc.SQL, tc.Options)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, q.String())
+			reparsed, err := ParseWithOptions(q.String(), tc.Options)
+			require.NoError(t, err)
+			require.Equal(t, q, reparsed, "Query didn't match after String() round trip")
+			reparsed, err = ParseWithOptions(q.Minify(), tc.Options)
+			require.NoError(t, err)
+			require.Equal(t, q, reparsed, "Query didn't match after Minify() round trip")
+		})
+	}
+}
+
+func TestMinify(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected string
+		Options  Options
+	}{
+		{SQL: "SELECT a, b FROM 'c' WHERE a = '1' AND b >= -2", Expected: "SELECT a,b FROM 'c' WHERE a='1' AND b>=-2"},
+		{SQL: "SELECT a FROM 'b' WHERE (a, c) IN (('1', 2)) AND d LIKE 'x%'", Expected: "SELECT a FROM 'b' WHERE (a,c) IN (('1',2)) AND d LIKE 'x%'"},
+		{SQL: "SELECT a FROM 'b' WHERE a > ANY ('1', '2') LIMIT 1", Expected: "SELECT a FROM 'b' WHERE a>ANY ('1','2') LIMIT 1"},
+		{SQL: "INSERT INTO 'a' (b, c) VALUES ('1', '2'), ('3', '4')", Expected: "INSERT INTO 'a' (b,c) VALUES ('1','2'),('3','4')"},
+		{SQL: "UPDATE 'a' SET b = '1', c = d WHERE e != ?", Expected: "UPDATE 'a' SET b='1',c=d WHERE e!=?"},
+		{SQL: "SELECT a FROM 'b' WHERE a->'c' = '1'", Expected: "SELECT a FROM 'b' WHERE a->'c' = '1'", Options: Options{Dialect: DialectPostgres}},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := ParseWithOptions(tc.SQL, tc.Options)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, q.Minify())
+		})
+	}
+}
+
+func TestParsePartial(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected query.Query
+		Err      string
+		Pos      int
+	}{
+		{
+			SQL: "SELECT a, b FROM 'c' WHERE a = '1'",
+			Expected: query.Query{
+				Type: query.Select, TableName: "c", Fields: []string{"a", "b"}, Aliases: []string{"", ""},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+		},
+		{
+			SQL:      "SELECT a FROM",
+			Expected: query.Query{Type: query.Select, Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      "at SELECT: expected quoted table name",
+			Pos:      13,
+		},
+		{
+			SQL: "SELECT a FROM 'b' WHERE c = '1' WHERE d = '2'",
+			Expected: query.Query{
+				Type: query.Select, TableName: "b", Fields: []string{"a"}, Aliases: []string{""},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: "at WHERE: unexpected WHERE",
+			Pos: 32,
+		},
+		{
+			SQL:      "SELECT a, coalesce(nullif(a, '') FROM 'b'",
+			Expected: query.Query{Type: query.Select, Fields: []string{"a"}, Aliases: []string{""}},
+			Err:      "at SELECT: unbalanced parentheses in expression",
+			Pos:      18,
+		},
+		{
+			SQL:      "  SELECT a AS",
+			Expected: query.Query{Type: query.Select, Fields: []string{"a"}},
+			Err:      "at AS: expected alias for a",
+			Pos:      13,
+		},
+		{
+			SQL: "SELECT a FROM 'c' WHERE a = '1' AND b >",
+			Expected: query.Query{
+				Type: query.Select, TableName: "c", Fields: []string{"a"}, Aliases: []string{""},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1Type: query.OpField, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1Type: query.OpField, Operator: query.Gt},
+				},
+			},
+			Err: "at WHERE: condition with empty right side operand",
+			Pos: 39,
+		},
+		{
+			SQL: "INSERT INTO 'a' (b, c, d) VALUES ('1', '2', '3'), ('4', '5', '6'), ('7', '8')",
+			Expected: query.Query{
+				Type: query.Insert, TableName: "a", Fields: []string{"b", "c", "d"},
+				Inserts: [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7", "8"}},
+			},
+			Err: "at INSERT INTO: row 3 has 2 values, expected 3",
+			Pos: 67,
+		},
+		{
+			SQL:      "SELECT x FROM (SELECT a FROM ) s",
+			Expected: query.Query{Type: query.Select, Fields: []string{"x"}, Aliases: []string{""}},
+			Err:      "at SELECT: expected quoted table name",
+			Pos:      28,
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := ParsePartial(tc.SQL)
+			require.Equal(t, tc.Expected, q)
+			if tc.Err == "" {
+				require.True(t, err.IsZero())
+			} else {
+				require.False(t, err.IsZero())
+				require.Equal(t, tc.Err, err.Error())
+				require.Equal(t, tc.Pos, err.Pos())
+			}
+		})
+	}
+}
+
+func TestNextExpected(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected []string
+	}{
+		{SQL: "", Expected: []string{"ALTER", "BEGIN", "COMMIT", "DELETE", "DESC", "DESCRIBE", "EXPLAIN", "INSERT", "ROLLBACK", "SAVEPOINT", "SELECT", "UPDATE"}},
+		{SQL: "EXPLAIN", Expected: []string{"ALTER", "BEGIN", "COMMIT", "DELETE", "DESC", "DESCRIBE", "INSERT", "ROLLBACK", "SAVEPOINT", "SELECT", "UPDATE"}},
+		{SQL: "BEGIN", Expected: []string{"TRANSACTION"}},
+		{SQL: "SELECT", Expected: []string{"*", "ADD", "ALL", "ALTER", "ANY", "BEGIN", "CASE", "COLLATE", "COLUMN", "COMMIT", "DESC", "DESCRIBE", "DISTINCT", "DROP", "ELSE", "END", "ESCAPE", "EXPLAIN", "INTERVAL", "LIMIT", "OFFSET", "ONLY", "OVER", "ROLLBACK", "SAVEPOINT", "TABLE", "THEN", "TRANSACTION", "WHEN"}},
+		{SQL: "SELECT a", Expected: []string{",", "AS", "FROM"}},
+		{SQL: "SELECT a AS b ", Expected: []string{",", "FROM"}},
+		{SQL: "SELECT a FROM 'b'", Expected: []string{"LIMIT", "OFFSET", "WHERE"}},
+		{SQL: "SELECT a FROM 'b' LIMIT 1", Expected: []string{"OFFSET"}},
+		{SQL: "SELECT a FROM 'b' WHERE", Expected: []string{"(", "ADD", "ALL", "ALTER", "ANY", "BEGIN", "CASE", "COLLATE", "COLUMN", "COMMIT", "DESC", "DESCRIBE", "DROP", "ELSE", "END", "ESCAPE", "EXPLAIN", "INTERVAL", "LIMIT", "NOT", "OFFSET", "ONLY", "OVER", "ROLLBACK", "SAVEPOINT", "TABLE", "THEN", "TRANSACTION", "WHEN"}},
+		{SQL: "SELECT a FROM 'b' WHERE a", Expected: []string{"!=", "<", "<=", "<>", "=", ">", ">=", "COLLATE", "IN", "LIKE", "NOT"}},
+		{SQL: "SELECT a FROM 'b' WHERE a =", Expected: []string{"ADD", "ALL", "ALTER", "ANY", "BEGIN", "CASE", "COLLATE", "COLUMN", "COMMIT", "DESC", "DESCRIBE", "DROP", "ELSE", "END", "ESCAPE", "EXPLAIN", "INTERVAL", "LIMIT", "OFFSET", "ONLY", "OVER", "ROLLBACK", "SAVEPOINT", "TABLE", "THEN", "TRANSACTION", "WHEN"}},
+		{SQL: "SELECT a FROM 'b' WHERE NOT (a = '1'", Expected: []string{")", "*", "COLLATE"}},
+		{SQL: "SELECT a FROM 'b' WHERE a = '1'", Expected: []string{"*", "AND", "COLLATE", "LIMIT", "OFFSET"}},
+		{SQL: "INSERT", Expected: []string{"INTO"}},
+		{SQL: "INSERT INTO 'a' (b", Expected: []string{")", ","}},
+		{SQL: "UPDATE 'a' SET b = '1'", Expected: []string{",", "WHERE"}},
+		{SQL: "SELECT a FROM 'b' garbage", Expected: nil},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			require.Equal(t, tc.Expected, NextExpected(tc.SQL))
+		})
+	}
+}
+
+func TestFormatQuotesKeywords(t *testing.T) {
+	for word := range reservedWords {
+		if isId, _ := isIdentifier("a" + word); !isId {
+			// not a keyword, but a symbol
+			continue
+		}
+		q := query.Query{Type: query.Select, Fields: []string{strings.ToLower(word)}, Aliases: []string{""}}
+		require.Equal(t, "SELECT \""+strings.ToLower(word)+"\"", q.Format(query.QuoteDouble), "keyword %s isn't quoted", word)
+	}
+}
+
 func BenchmarkSQLSelect(b *testing.B) {
 	sql := "SELECT a AS text FROM 'b' WHERE c = 'c' AND d = 'd'"
 	for i := 0; i < b.N; i++ {
@@ -628,6 +2536,93 @@ func BenchmarkSQLInsert(b *testing.B) {
 	}
 }
 
+func BenchmarkSQLInsertRows(b *testing.B) {
+	sql := "INSERT INTO 'a' (b, c, d) VALUES ('1', '2', '3'), ('4', '5', '6'), ('7', '8', '9'), ('10', '11', '12')"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q, err := Parse(sql)
+		if err != nil {
+			b.Errorf("Error should have been %v: %v", err, q)
+		}
+	}
+}
+
+func BenchmarkCachingParserHit(b *testing.B) {
+	sql := "SELECT a AS text FROM 'b' WHERE c = 'c' AND d = 'd'"
+	c := NewCachingParser(10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q, err := c.Parse(sql)
+		if err != nil {
+			b.Errorf("Error should have been %v: %v", err, q)
+		}
+	}
+}
+
+func BenchmarkSQLSelectInList(b *testing.B) {
+	sql := inListSQL(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q, err := Parse(sql)
+		if err != nil {
+			b.Errorf("Error should have been %v: %v", err, q)
+		}
+	}
+}
+
+func TestLargeInList(t *testing.T) {
+	q, err := Parse(inListSQL(10000))
+	require.NoError(t, err)
+	require.Len(t, q.Conditions[0].Operand2List, 10000)
+	require.Equal(t, query.Operand{Value: "9999", Type: query.OpNumber}, q.Conditions[0].Operand2List[9999])
+
+	// the list is allocated once, so the allocations don't grow with its length
+	allocs := func(n int) float64 {
+		sql := inListSQL(n)
+		return testing.AllocsPerRun(5, func() {
+			_, _ = Parse(sql)
+		})
+	}
+	small, large := allocs(10), allocs(10000)
+	require.True(t, large <= small+10, "%v allocations for 10000 values, %v for 10", large, small)
+}
+
+func TestInsertRowsPresize(t *testing.T) {
+	// parens in quoted values aren't rows, a single row of 50 fields mustn't pre-size a value per paren
+	fields := make([]string, 50)
+	values := make([]string, 50)
+	for i := range fields {
+		fields[i] = "f" + strconv.Itoa(i)
+		values[i] = "'1'"
+	}
+	values[0] = "'" + strings.Repeat("(", 200000) + "'"
+	sql := "INSERT INTO 'a' (" + strings.Join(fields, ", ") + ") VALUES (" + strings.Join(values, ", ") + ")"
+	require.Equal(t, 1, countRows(sql[strings.Index(sql, "VALUES")+len("VALUES"):]))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	q, err := Parse(sql)
+	runtime.ReadMemStats(&after)
+	require.NoError(t, err)
+	require.Len(t, q.Inserts, 1)
+	allocated := after.TotalAlloc - before.TotalAlloc
+	require.True(t, allocated < uint64(10*len(sql)), "allocated %d bytes for %d bytes of SQL", allocated, len(sql))
+}
+
+// inListSQL returns a SELECT with the condition id IN (0, 1, ..., n-1)
+func inListSQL(n int) string {
+	var sb strings.Builder
+	sb.WriteString("SELECT a FROM 'b' WHERE id IN (")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Itoa(i))
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
 func createReadme(out output) {
 	content, err := ioutil.ReadFile("README.template")
 	if err != nil {
@@ -642,3 +2637,357 @@ func createReadme(out output) {
 		log.Fatal(err)
 	}
 }
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestSentinelErrors(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected error
+	}{
+		{SQL: "", Expected: ErrEmptyQuery},
+		{SQL: "SELECT", Expected: ErrNoTable},
+		{SQL: "SELECT a FROM 'b' WHERE", Expected: ErrEmptyWhere},
+		{SQL: "DELETE FROM 'b' WHERE ", Expected: ErrEmptyWhere},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			_, err := Parse(tc.SQL)
+			require.True(t, errors.Is(err, tc.Expected), "got %v", err)
+			require.Equal(t, tc.Expected.Error(), err.Error())
+			var errPos *ErrorWithPos
+			require.True(t, errors.As(err, &errPos))
+		})
+	}
+
+	_, err := Parse("SELECT a FROM 'b' WHERE a >")
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrEmptyWhere))
+}
+
+func TestIsKeyword(t *testing.T) {
+	require.True(t, IsKeyword("SELECT"))
+	require.True(t, IsKeyword("select"))
+	require.True(t, IsKeyword("Distinct"))
+	require.False(t, IsKeyword("a"))
+	require.False(t, IsKeyword(""))
+	require.False(t, IsKeyword("="))
+	require.False(t, IsKeyword("top"))
+	// a reserved word must be in the shared keyword list
+	for word := range reservedWords {
+		if isIdentifierStart(word[0]) {
+			require.True(t, IsKeyword(word), "reserved word %s isn't a keyword", word)
+		}
+	}
+
+	require.True(t, IsDialectKeyword("where", DialectSQLServer))
+	require.True(t, IsDialectKeyword("top", DialectSQLServer))
+	require.False(t, IsDialectKeyword("top", DialectPostgres))
+}
+
+// TestKeywordColumns checks that keywords matched only in their own step are valid column names
+func TestKeywordColumns(t *testing.T) {
+	ts := []struct {
+		SQL    string
+		Fields []string
+	}{
+		{SQL: "SELECT a, column FROM 't'", Fields: []string{"a", "column"}},
+		{SQL: "SELECT alter, table, add, drop FROM 't' WHERE column = '1'", Fields: []string{"alter", "table", "add", "drop"}},
+		{SQL: "SELECT any, all FROM 't' WHERE any = '1' AND all > ANY ('1', '2') AND a = all", Fields: []string{"any", "all"}},
+		{SQL: "SELECT escape FROM 't' WHERE escape = '1' AND a LIKE 'b!%' ESCAPE '!'", Fields: []string{"escape"}},
+		{SQL: "SELECT begin, rollback, savepoint, transaction FROM 't' WHERE commit = '1'", Fields: []string{"begin", "rollback", "savepoint", "transaction"}},
+		{SQL: "SELECT case, when, then, else, end FROM 't' WHERE end = '1'", Fields: []string{"case", "when", "then", "else", "end"}},
+		{SQL: "SELECT case AS c, CASE WHEN a = '1' THEN 'x' END FROM 't'", Fields: []string{"case", "CASE WHEN a = '1' THEN 'x' END"}},
+		{SQL: "SELECT interval FROM 't' WHERE interval = '1' AND a > interval AND b < now() - interval", Fields: []string{"interval"}},
+		{SQL: "SELECT only FROM 't' WHERE only = '1'", Fields: []string{"only"}},
+		{SQL: "SELECT limit, offset FROM 't' WHERE limit = '1' AND offset > limit LIMIT 1 OFFSET 2", Fields: []string{"limit", "offset"}},
+		{SQL: "SELECT desc, describe FROM 't' WHERE explain = '1'", Fields: []string{"desc", "describe"}},
+		{SQL: "SELECT over, row_number() OVER (ORDER BY a) AS over FROM 't' WHERE over = '1'", Fields: []string{"over", "row_number() OVER (ORDER BY a)"}},
+		{SQL: "SELECT collate FROM 't' WHERE collate = 'a' COLLATE utf8_bin AND b = collate", Fields: []string{"collate"}},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, tc.Fields, q.Fields)
+		})
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	// a query using each feature by FeatureSet field name, it must parse if and only if the feature is
+	// reported, a new field fails the test until it has a probe
+	probes := map[string]string{
+		"Joins":               "SELECT a FROM 'b' JOIN 'c' ON d = e",
+		"CTEs":                "WITH c AS (SELECT a FROM 'b') SELECT a FROM 'c'",
+		"Subqueries":          "SELECT a FROM 'b' WHERE a IN (SELECT c FROM 'd')",
+		"DerivedTables":       "SELECT x FROM (SELECT a AS x FROM 'b') sub",
+		"WindowFunctions":     "SELECT rank() OVER (ORDER BY a) FROM 'b'",
+		"LimitOffset":         "SELECT a FROM 'b' LIMIT 1 OFFSET 2",
+		"Placeholders":        "SELECT a FROM 'b' WHERE a = ? LIMIT $2",
+		"Top":                 "SELECT TOP 1 a FROM 'b'",
+		"TableStatement":      "TABLE 'b'",
+		"Only":                "SELECT a FROM ONLY 'b'",
+		"UpdateFrom":          "UPDATE 'a' SET x = b.y FROM 'b' WHERE a.id = b.id",
+		"DeleteUsing":         "DELETE FROM 'a' USING 'b' WHERE a.id = b.id",
+		"DistinctFrom":        "SELECT a FROM 'b' WHERE a IS DISTINCT FROM c",
+		"Casts":               "SELECT a FROM 'b' WHERE a = '1'::int",
+		"JSONPath":            "SELECT a FROM 'b' WHERE a->'c' = '1'",
+		"FullTextMatch":       "SELECT a FROM 'b' WHERE MATCH (a) AGAINST ('c')",
+		"DoubleQuotedStrings": `INSERT INTO 'a' (b) VALUES ("c")`,
+		"Transactions":        "BEGIN TRANSACTION",
+		"Explain":             "EXPLAIN SELECT a FROM 'b'",
+	}
+	fields := reflect.TypeOf(FeatureSet{})
+	require.Equal(t, fields.NumField(), len(probes), "a probe for each FeatureSet field")
+	for dialect, name := range DialectString {
+		features := reflect.ValueOf(Features(Dialect(dialect)))
+		for i := 0; i < fields.NumField(); i++ {
+			field := fields.Field(i).Name
+			t.Run(name+"/"+field, func(t *testing.T) {
+				sql, ok := probes[field]
+				require.True(t, ok, "no probe for %s", field)
+				_, err := ParseWithOptions(sql, Options{Dialect: Dialect(dialect)})
+				require.Equal(t, features.FieldByName(field).Bool(), err == nil, "%s: %v", sql, err)
+			})
+		}
+	}
+}
+
+func TestTableStatement(t *testing.T) {
+	opts := Options{Dialect: DialectPostgres}
+	for sql, equivalent := range map[string]string{
+		"TABLE 'a'":               "SELECT * FROM 'a'",
+		"table only 'a' limit 10": "SELECT * FROM ONLY 'a' LIMIT 10",
+	} {
+		expected, err := ParseWithOptions(equivalent, opts)
+		require.NoError(t, err)
+		actual, err := ParseWithOptions(sql, opts)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual, sql)
+	}
+
+	_, err := ParseWithOptions("TABLE 'a' WHERE b = '1'", opts)
+	require.EqualError(t, err, "expected end of query")
+	_, err = Parse("TABLE 'a'")
+	require.EqualError(t, err, "at TABLE: TABLE is only supported by the Postgres dialect")
+}
+
+func TestNewInsertParses(t *testing.T) {
+	built, err := query.NewInsert("a", "b", "c").
+		AddRow(query.Operand{Value: "1", Type: query.OpQuoted}, query.Operand{Value: `it's \' OR 1=1 --`, Type: query.OpQuoted}).
+		AddRow(query.Operand{Value: "2", Type: query.OpQuoted}, query.Operand{Value: "3", Type: query.OpQuoted}).
+		Build()
+	require.NoError(t, err)
+	parsed, err := Parse(built.String())
+	require.NoError(t, err)
+	require.Equal(t, built, parsed)
+}
+
+func TestWildcard(t *testing.T) {
+	q, err := Parse("SELECT a, * FROM 'b'")
+	require.NoError(t, err)
+	require.False(t, q.IsWildcardSelect())
+	require.True(t, q.HasWildcard())
+
+	q, err = Parse("SELECT * FROM 'b' WHERE a = '1'")
+	require.NoError(t, err)
+	require.True(t, q.IsWildcardSelect())
+}
+
+func TestPlaceholderCount(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected int
+	}{
+		{SQL: "SELECT a FROM 'b' WHERE a = '1'", Expected: 0},
+		{SQL: "SELECT a FROM 'b' LIMIT ?", Expected: 1},
+		{SQL: "SELECT a FROM 'b' WHERE a = ? AND c IN (?, ?) LIMIT ? OFFSET ?", Expected: 5},
+		{SQL: "DELETE FROM 'a' WHERE (b, c) IN ((?, '1'), ($2, $3))", Expected: 3},
+		{SQL: "UPDATE 'a' SET b = ?, c = '1' WHERE d = ?", Expected: 2},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, q.PlaceholderCount())
+		})
+	}
+}
+
+func TestLiterals(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected []query.Operand
+	}{
+		{
+			SQL:      "UPDATE 'a' SET a = NULL, b = TRUE WHERE c = 'd'",
+			Expected: []query.Operand{{Value: "NULL", Type: query.OpNull}, {Value: "TRUE", Type: query.OpBool}, {Value: "d", Type: query.OpQuoted}},
+		},
+		{
+			SQL:      "SELECT a FROM 'b' WHERE c > INTERVAL '1' DAY AND d = x'1F'",
+			Expected: []query.Operand{{Value: "INTERVAL '1' DAY", Type: query.OpInterval}, {Value: "x'1F'", Type: query.OpHex}},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, q.Literals())
+		})
+	}
+}
+
+func TestCachingParser(t *testing.T) {
+	c := NewCachingParser(2)
+	q, err := c.Parse("SELECT a FROM 'b' WHERE c IN (1, 2)")
+	require.NoError(t, err)
+	expected, err := Parse("SELECT a FROM 'b' WHERE c IN (1, 2)")
+	require.NoError(t, err)
+	require.Equal(t, expected, q)
+
+	// the cached query isn't changed by the caller
+	q.Fields[0] = "x"
+	q.Conditions[0].Operand2List[0].Value = "3"
+	q, err = c.Parse("SELECT a FROM 'b' WHERE c IN (1, 2)")
+	require.NoError(t, err)
+	require.Equal(t, expected, q)
+	require.Equal(t, 1, c.Len())
+
+	_, err = c.Parse("SELECT FROM 'b'")
+	require.EqualError(t, err, "at SELECT: expected field to SELECT")
+	require.Equal(t, 1, c.Len())
+
+	_, err = c.Parse("SELECT b FROM 'c'")
+	require.NoError(t, err)
+	_, err = c.Parse("SELECT a FROM 'b' WHERE c IN (1, 2)")
+	require.NoError(t, err)
+	_, err = c.Parse("SELECT c FROM 'd'")
+	require.NoError(t, err)
+	require.Equal(t, 2, c.Len())
+	require.Contains(t, c.items, "SELECT a FROM 'b' WHERE c IN (1, 2)", "recently used query was evicted")
+	require.NotContains(t, c.items, "SELECT b FROM 'c'")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sql := "SELECT a FROM 'b' WHERE c = " + strconv.Itoa((i+j)%4)
+				q, err := c.Parse(sql)
+				require.NoError(t, err)
+				require.Equal(t, strconv.Itoa((i+j)%4), q.Conditions[0].Operand2)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 2, c.Len())
+
+	c = NewCachingParserWithOptions(0, Options{Dialect: DialectPostgres})
+	_, err = c.Parse("TABLE 'a'")
+	require.NoError(t, err)
+	require.Equal(t, 0, c.Len())
+}
+
+func TestOperandCount(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected int
+	}{
+		{SQL: "SELECT a FROM 'b'", Expected: 0},
+		{SQL: "SELECT a FROM 'b' WHERE c = '1' AND d IN (1, 2)", Expected: 5},
+		{SQL: "DELETE FROM 'a' WHERE (b, c) IN (('1', '2'), ('3', '4'))", Expected: 6},
+		{SQL: "UPDATE 'a' SET b = ?, c = '1' WHERE d = ?", Expected: 4},
+		{SQL: "INSERT INTO 'a' (b, c) VALUES ('1', '2'), ('3', '4')", Expected: 4},
+		{SQL: "SELECT x FROM (SELECT a AS x FROM 't' WHERE b = 1) AS s WHERE x > 2", Expected: 4},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, q.OperandCount())
+			require.Zero(t, testing.AllocsPerRun(10, func() { q.OperandCount() }))
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	q1, err := Parse("SELECT a, b FROM 'c' WHERE a = '1' AND b IN (1, 2)")
+	require.NoError(t, err)
+	q2, err := Parse("select  a,b from 'c' /* comment */ where a='1' and b in (1,2)")
+	require.NoError(t, err)
+	require.Equal(t, q1.Hash(), q2.Hash())
+	q3, err := Parse("SELECT a, b FROM 'c' WHERE a = '1' AND b IN (1.0, 2.00)")
+	require.NoError(t, err)
+	require.Equal(t, q1.Hash(), q3.Hash(), "equal numbers must have the same hash")
+
+	for _, sql := range []string{
+		"SELECT a, b FROM 'c' WHERE a = '2' AND b IN (1, 2)",
+		"SELECT a, b FROM 'c' WHERE a = b AND b IN (1, 2)",
+		"SELECT a, b FROM 'c' WHERE a != '1' AND b IN (1, 2)",
+		"SELECT a, b FROM 'c' WHERE a = '1' AND b NOT IN (1, 2)",
+		"SELECT a, b FROM 'd' WHERE a = '1' AND b IN (1, 2)",
+		"SELECT b, a FROM 'c' WHERE a = '1' AND b IN (1, 2)",
+		"SELECT DISTINCT a, b FROM 'c' WHERE a = '1' AND b IN (1, 2)",
+		"SELECT a, b FROM 'c' WHERE a = '1' AND b IN (1.1, 2)",
+	} {
+		q, err := Parse(sql)
+		require.NoError(t, err)
+		require.NotEqual(t, q1.Hash(), q.Hash(), sql)
+	}
+
+	u1, err := Parse("UPDATE 'a' SET b = '1', c = '2' WHERE d = '3'")
+	require.NoError(t, err)
+	u2, err := Parse("UPDATE 'a' SET c = '2', b = '1' WHERE d = '3'")
+	require.NoError(t, err)
+	require.Equal(t, u1.Hash(), u2.Hash())
+}
+
+func TestDebug(t *testing.T) {
+	q, err := Parse("SELECT a AS c, b FROM 'd' WHERE a = '1' AND NOT (b IN (1, c)) AND e LIKE 'x!%' ESCAPE '!'")
+	require.NoError(t, err)
+	require.Equal(t, `Select
+  TableName: d
+  Fields:
+    a AS c
+    b
+  Conditions:
+    - Eq
+      Operand1: a (OpField)
+      Operand2: 1 (OpQuoted)
+    - Not In
+      Operand1: b (OpField)
+      Operand2: [1 (OpNumber), c (OpField)] (OpList)
+    - Like
+      Operand1: e (OpField)
+      Operand2: x!% (OpQuoted)
+      Escape: !
+`, q.Debug())
+
+	q, err = Parse("UPDATE 'a' SET b = c, d = 'x' WHERE e > 2")
+	require.NoError(t, err)
+	require.Equal(t, `Update
+  TableName: a
+  Updates:
+    b = c (OpField)
+    d = x (OpQuoted)
+  Conditions:
+    - Gt
+      Operand1: e (OpField)
+      Operand2: 2 (OpNumber)
+`, q.Debug())
+}
+
+func BenchmarkSQLSelectSingleCondition(b *testing.B) {
+	sql := "SELECT a FROM 'b' WHERE c = 'c'"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q, err := Parse(sql)
+		if err != nil {
+			b.Errorf("Error should have been %v: %v", err, q)
+		}
+	}
+}